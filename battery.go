@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+// Battery states reported by the kernel in POWER_SUPPLY_STATUS
+const (
+	batteryCharging    = "Charging"
+	batteryDischarging = "Discharging"
+	batteryFull        = "Full"
+	batteryNotCharging = "Not charging"
+	batteryUnknown     = "Unknown"
+)
+
+// batteryCollector reports every battery and AC adapter under
+// <sys_root>/class/power_supply
+type batteryCollector struct{}
+
+func init() {
+	RegisterCollector(&batteryCollector{})
+	onConfigLoad(warnBatteryPath)
+}
+
+// Name returns the data type name
+func (b *batteryCollector) Name() string {
+	return "battery"
+}
+
+// Interval returns the configured polling interval
+func (b *batteryCollector) Interval() time.Duration {
+	return currentConfig().Section("battery").Duration("interval", 3*time.Second)
+}
+
+// Snapshot reads all power supplies
+func (b *batteryCollector) Snapshot() (any, error) {
+	return readPowerSupplies(batterySysRoot())
+}
+
+// Start emits the power supplies every interval and as soon as a charger
+// or battery changes, until ctx is cancelled
+func (b *batteryCollector) Start(ctx context.Context, emit EmitFunc) error {
+	refresh := func() { publishSnapshot(b, emit) }
+	pollPowerSupplies(ctx, b.Interval, refresh, refresh)
+	return nil
+}
+
+// batterySysRoot returns the sysfs root configured in [battery]
+func batterySysRoot() string {
+	return configuredBatterySysRoot(currentConfig())
+}
+
+// configuredBatterySysRoot returns sys_root of [battery]. Without it, the
+// sysfs root is taken from the deprecated battery_path of [system], e.g.
+// "/sys/class/power_supply/BAT0".
+func configuredBatterySysRoot(cfg *Config) string {
+	if root := cfg.Section("battery").String("sys_root", ""); root != "" {
+		return root
+	}
+	if root, ok := legacyBatterySysRoot(cfg); ok {
+		return root
+	}
+	return "/sys"
+}
+
+// legacyBatterySysRoot returns the sysfs root of battery_path in [system],
+// if it is set and points into class/power_supply
+func legacyBatterySysRoot(cfg *Config) (string, bool) {
+	path := cfg.Section("system").String("battery_path", "")
+	if path == "" {
+		return "", false
+	}
+	supplies := filepath.Dir(filepath.Clean(path))
+	if filepath.Base(supplies) != "power_supply" || filepath.Base(filepath.Dir(supplies)) != "class" {
+		return "", false
+	}
+	return filepath.Dir(filepath.Dir(supplies)), true
+}
+
+// warnBatteryPath logs that battery_path in [system] is deprecated
+func warnBatteryPath(cfg *Config) {
+	path := cfg.Section("system").String("battery_path", "")
+	if path == "" {
+		return
+	}
+	root, ok := legacyBatterySysRoot(cfg)
+	switch {
+	case cfg.Section("battery").String("sys_root", "") != "":
+		log.Printf("battery_path in [system] is deprecated and ignored since [battery] sets sys_root")
+	case ok:
+		log.Printf("battery_path in [system] is deprecated, use sys_root = %q in [battery]; every battery is reported, not only %s", root, filepath.Base(path))
+	default:
+		log.Printf("battery_path in [system] is deprecated and ignored: %s is not in class/power_supply; use sys_root in [battery]", path)
+	}
+}
+
+// readPowerSupplies reads the batteries and adapters of the system.
+// Batteries of peripherals such as mice (scope "Device") are ignored.
+func readPowerSupplies(sysRoot string) (*types.PowerInfo, error) {
+	dirs, err := filepath.Glob(filepath.Join(sysRoot, "class", "power_supply", "*"))
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(dirs, naturalCompare)
+
+	info := &types.PowerInfo{Batteries: []types.BatteryInfo{}, Adapters: []types.ACAdapter{}}
+	var rates []float64
+	for _, dir := range dirs {
+		props, err := readUevent(filepath.Join(dir, "uevent"))
+		if err != nil || props["SCOPE"] == "Device" {
+			continue
+		}
+		if props["TYPE"] == "Battery" {
+			if props["PRESENT"] == "0" {
+				continue
+			}
+			battery, rate := readBattery(dir, props)
+			info.Batteries = append(info.Batteries, battery)
+			rates = append(rates, rate)
+			continue
+		}
+		// Mains, USB, USB_C and other supplies that report whether they
+		// are plugged in
+		if online, ok := props["ONLINE"]; ok {
+			adapter := types.ACAdapter{Name: filepath.Base(dir), Online: online != "0"}
+			info.Adapters = append(info.Adapters, adapter)
+			info.ACOnline = info.ACOnline || adapter.Online
+		}
+	}
+	info.Total = aggregateBatteries(info.Batteries, rates)
+	return info, nil
+}
+
+// readUevent parses the KEY=VALUE lines of a power_supply uevent file,
+// without the POWER_SUPPLY_ prefix
+func readUevent(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	props := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if ok {
+			props[strings.TrimPrefix(key, "POWER_SUPPLY_")] = value
+		}
+	}
+	return props, scanner.Err()
+}
+
+// readBattery converts the uevent properties of the battery in dir and
+// returns it with its smoothed charge or discharge rate. Batteries report
+// either energy (µWh) and power (µW), or charge (µAh) and current (µA)
+// which are converted with the voltage (µV).
+func readBattery(dir string, props map[string]string) (types.BatteryInfo, float64) {
+	name := filepath.Base(dir)
+	micro := func(key string) (float64, bool) {
+		value, err := strconv.ParseFloat(props[key], 64)
+		return value / 1e6, err == nil
+	}
+	voltage, _ := micro("VOLTAGE_NOW")
+	// Capacities are rated at the design voltage, the current voltage
+	// varies with the charge
+	designVoltage, ok := micro("VOLTAGE_MIN_DESIGN")
+	if !ok || designVoltage <= 0 {
+		designVoltage = voltage
+	}
+	// Charge-based batteries convert their current with the same voltage as
+	// their charge, so the time estimates equal charge divided by current
+	if _, ok := micro("ENERGY_NOW"); !ok {
+		voltage = designVoltage
+	}
+	energy := func(energyKey, chargeKey string) float64 {
+		if wh, ok := micro(energyKey); ok {
+			return wh
+		}
+		ah, _ := micro(chargeKey)
+		return ah * designVoltage
+	}
+
+	battery := types.BatteryInfo{
+		Name:       name,
+		State:      props["STATUS"],
+		EnergyNow:  energy("ENERGY_NOW", "CHARGE_NOW"),
+		EnergyFull: energy("ENERGY_FULL", "CHARGE_FULL"),
+	}
+	if battery.State == "" {
+		battery.State = batteryUnknown
+	}
+	if power, ok := micro("POWER_NOW"); ok {
+		battery.Power = math.Abs(power)
+	} else {
+		// Some drivers report a negative current while discharging
+		current, _ := micro("CURRENT_NOW")
+		battery.Power = math.Abs(current) * voltage
+	}
+	if capacity, err := strconv.Atoi(props["CAPACITY"]); err == nil {
+		battery.Percentage = capacity
+	} else if battery.EnergyFull > 0 {
+		battery.Percentage = int(battery.EnergyNow / battery.EnergyFull * 100)
+	}
+
+	health := &types.BatteryHealth{
+		Manufacturer:     props["MANUFACTURER"],
+		Model:            props["MODEL_NAME"],
+		SerialNumber:     props["SERIAL_NUMBER"],
+		Technology:       props["TECHNOLOGY"],
+		EnergyFullDesign: energy("ENERGY_FULL_DESIGN", "CHARGE_FULL_DESIGN"),
+	}
+	// Firmware that does not count cycles reports 0
+	if cycles, err := strconv.Atoi(props["CYCLE_COUNT"]); err == nil && cycles > 0 {
+		health.CycleCount = &cycles
+	}
+	if health.EnergyFullDesign > 0 && battery.EnergyFull > 0 {
+		wear := max(1-battery.EnergyFull/health.EnergyFullDesign, 0) * 100
+		health.Wear = &wear
+	}
+	battery.Health = health
+
+	// Thresholds are only exposed as attributes, not in uevent
+	if start, ok := readSysfsIntOK(filepath.Join(dir, "charge_control_start_threshold")); ok {
+		battery.ChargeStartThreshold = &start
+	}
+	if end, ok := readSysfsIntOK(filepath.Join(dir, "charge_control_end_threshold")); ok {
+		battery.ChargeEndThreshold = &end
+	}
+
+	rate := smoothBatteryRate(name, battery.State, battery.Power)
+	setTimeEstimates(&battery, rate)
+	return battery, rate
+}
+
+// Smoothed charge or discharge rate of every battery, keyed by name
+var batteryRates = struct {
+	sync.Mutex
+	m map[string]smoothedRate
+}{m: make(map[string]smoothedRate)}
+
+type smoothedRate struct {
+	value float64
+	state string
+	at    time.Time
+}
+
+// smoothBatteryRate returns an exponential moving average of the rate of a
+// battery, so the time estimates do not jump with every load spike. The
+// weight of each sample depends on the time since the previous one, with a
+// time constant set by rate_smoothing in [battery]. The average restarts
+// when the battery state changes.
+func smoothBatteryRate(name, state string, rate float64) float64 {
+	batteryRates.Lock()
+	defer batteryRates.Unlock()
+
+	now := time.Now()
+	prev, ok := batteryRates.m[name]
+	if ok && prev.state == state && rate > 0 && prev.value > 0 {
+		tau := currentConfig().Section("battery").Duration("rate_smoothing", 30*time.Second)
+		alpha := 1 - math.Exp(-now.Sub(prev.at).Seconds()/tau.Seconds())
+		rate = prev.value + alpha*(rate-prev.value)
+	}
+	batteryRates.m[name] = smoothedRate{value: rate, state: state, at: now}
+	return rate
+}
+
+// aggregateBatteries combines batteries into one, weighting each by its
+// energy so that a small and a large battery are not averaged evenly
+func aggregateBatteries(batteries []types.BatteryInfo, rates []float64) types.BatteryInfo {
+	total := types.BatteryInfo{State: batteryUnknown}
+	if len(batteries) == 0 {
+		return total
+	}
+
+	states := make([]string, 0, len(batteries))
+	percentages := 0
+	for _, battery := range batteries {
+		total.EnergyNow += battery.EnergyNow
+		total.EnergyFull += battery.EnergyFull
+		percentages += battery.Percentage
+		states = append(states, battery.State)
+	}
+	if total.EnergyFull > 0 {
+		total.Percentage = int(total.EnergyNow / total.EnergyFull * 100)
+	} else {
+		total.Percentage = percentages / len(batteries)
+	}
+
+	// One battery charging or discharging (they are used one after the
+	// other on ThinkPads) decides the state of the whole system
+	switch {
+	case slices.Contains(states, batteryDischarging):
+		total.State = batteryDischarging
+	case slices.Contains(states, batteryCharging):
+		total.State = batteryCharging
+	case !slices.ContainsFunc(states, func(s string) bool { return s != batteryFull }):
+		total.State = batteryFull
+	case slices.Contains(states, batteryNotCharging):
+		total.State = batteryNotCharging
+	}
+
+	// Only batteries in that state contribute to the rate
+	rate := 0.0
+	for i, battery := range batteries {
+		if battery.State == total.State {
+			total.Power += battery.Power
+			rate += rates[i]
+		}
+	}
+	setTimeEstimates(&total, rate)
+	return total
+}
+
+// setTimeEstimates computes the time to empty or full from the energy and
+// the rate. Estimates that cannot be made are left nil.
+func setTimeEstimates(battery *types.BatteryInfo, rate float64) {
+	minutes := func(energy float64) *float64 {
+		value := max(energy, 0) / rate * 60
+		return &value
+	}
+	switch {
+	case battery.State == batteryFull:
+		zero := 0.0
+		battery.TimeToFull = &zero
+	case rate <= 0 || battery.EnergyFull <= 0:
+		// No rate, or no capacity to relate it to
+	case battery.State == batteryDischarging:
+		battery.TimeToEmpty = minutes(battery.EnergyNow)
+	case battery.State == batteryCharging:
+		battery.TimeToFull = minutes(battery.EnergyFull - battery.EnergyNow)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+// batteryEventCollector publishes discrete battery transitions, so clients
+// can notify once instead of comparing every battery update:
+//
+//	[battery]
+//	alert_levels = [20, 10, 5]
+type batteryEventCollector struct{}
+
+func init() {
+	RegisterCollector(&batteryEventCollector{})
+}
+
+// Name returns the data type name
+func (b *batteryEventCollector) Name() string {
+	return "battery_event"
+}
+
+// Interval returns the polling interval of the battery state
+func (b *batteryEventCollector) Interval() time.Duration {
+	return currentConfig().Section("battery").Duration("interval", 3*time.Second)
+}
+
+// Snapshot returns no data, events have no current state
+func (b *batteryEventCollector) Snapshot() (any, error) {
+	return nil, nil
+}
+
+// SubscribeOnly marks the collector as SUB only
+func (b *batteryEventCollector) SubscribeOnly() {}
+
+// Start compares the combined battery state every interval and whenever a
+// power supply changes, and emits an event for every transition until ctx
+// is cancelled
+func (b *batteryEventCollector) Start(ctx context.Context, emit EmitFunc) error {
+	var tracker batteryEventTracker
+	check := func() {
+		power, err := readPowerSupplies(batterySysRoot())
+		if err != nil {
+			reportCollectorError(b.Name(), err)
+			return
+		}
+		if len(power.Batteries) == 0 {
+			return
+		}
+		levels := currentConfig().Section("battery").Ints("alert_levels", []int{20, 10, 5})
+		for _, event := range tracker.update(power.Total, levels) {
+			emit(b.Name(), wrapData(b, event))
+		}
+	}
+	pollPowerSupplies(ctx, b.Interval, check, check)
+	return nil
+}
+
+// batteryEventTracker turns successive battery states into events
+type batteryEventTracker struct {
+	prev *types.BatteryInfo
+	// Alert levels already reported. A level is re-armed once the charge
+	// rises above it or the state changes, so a charge that wobbles around
+	// a level reports it only once.
+	reported map[int]bool
+}
+
+// update returns the events between the previous state and cur. The first
+// state only sets the baseline: levels at or above its charge count as
+// reported.
+func (t *batteryEventTracker) update(cur types.BatteryInfo, levels []int) []types.BatteryEvent {
+	prev := t.prev
+	t.prev = &cur
+	if t.reported == nil {
+		t.reported = make(map[int]bool)
+	}
+	if prev != nil && prev.State != cur.State {
+		clear(t.reported)
+	}
+	for level := range t.reported {
+		if cur.Percentage > level {
+			delete(t.reported, level)
+		}
+	}
+	if prev == nil {
+		for _, level := range levels {
+			if cur.Percentage <= level {
+				t.reported[level] = true
+			}
+		}
+		return nil
+	}
+
+	events := batteryTransitions(*prev, cur)
+
+	// A drop past several alert levels at once reports only the lowest one
+	if cur.State == batteryDischarging {
+		var crossed []int
+		for _, level := range levels {
+			if cur.Percentage <= level && !t.reported[level] {
+				crossed = append(crossed, level)
+				t.reported[level] = true
+			}
+		}
+		if len(crossed) > 0 {
+			low := batteryEvent(types.BatteryLow, *prev, cur)
+			low.Level = slices.Min(crossed)
+			events = append(events, low)
+		}
+	}
+	return events
+}
+
+// batteryTransitions returns the state change events between two battery
+// states. Charging that ends because the battery is full is reported as
+// "full" only, not as "charging_stopped" too.
+func batteryTransitions(prev, cur types.BatteryInfo) []types.BatteryEvent {
+	switch {
+	case cur.State == batteryFull && prev.State != batteryFull:
+		return []types.BatteryEvent{batteryEvent(types.BatteryFull, prev, cur)}
+	case cur.State == batteryCharging && prev.State != batteryCharging:
+		return []types.BatteryEvent{batteryEvent(types.BatteryChargingStarted, prev, cur)}
+	case prev.State == batteryCharging && cur.State != batteryCharging:
+		return []types.BatteryEvent{batteryEvent(types.BatteryChargingStopped, prev, cur)}
+	}
+	return nil
+}
+
+// batteryEvent returns an event of the given kind between two states
+func batteryEvent(kind string, prev, cur types.BatteryInfo) types.BatteryEvent {
+	return types.BatteryEvent{
+		Event:              kind,
+		PreviousPercentage: prev.Percentage,
+		Percentage:         cur.Percentage,
+		PreviousState:      prev.State,
+		State:              cur.State,
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+func TestBatteryEventTracker(t *testing.T) {
+	levels := []int{20, 10, 5}
+	type step struct {
+		percentage int
+		state      string
+		events     []string // "low:<level>" for low events
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"first state has no events", []step{
+			{50, batteryCharging, nil},
+		}},
+		{"no alert at startup below a level", []step{
+			{8, batteryDischarging, nil},
+			{7, batteryDischarging, nil},
+			{5, batteryDischarging, []string{"low:5"}},
+		}},
+		{"each level once", []step{
+			{22, batteryDischarging, nil},
+			{20, batteryDischarging, []string{"low:20"}},
+			{15, batteryDischarging, nil},
+			{10, batteryDischarging, []string{"low:10"}},
+		}},
+		{"drop past several levels reports the lowest", []step{
+			{25, batteryDischarging, nil},
+			{9, batteryDischarging, []string{"low:10"}},
+			{5, batteryDischarging, []string{"low:5"}},
+		}},
+		{"wobbling around a level reports it once", []step{
+			{21, batteryDischarging, nil},
+			{20, batteryDischarging, []string{"low:20"}},
+			{20, batteryDischarging, nil},
+			{20, batteryDischarging, nil},
+			{19, batteryDischarging, nil},
+		}},
+		{"rising above a level re-arms it", []step{
+			{21, batteryDischarging, nil},
+			{20, batteryDischarging, []string{"low:20"}},
+			{21, batteryDischarging, nil},
+			{20, batteryDischarging, []string{"low:20"}},
+		}},
+		{"state change re-arms levels", []step{
+			{11, batteryDischarging, nil},
+			{10, batteryDischarging, []string{"low:10"}},
+			{10, batteryCharging, []string{types.BatteryChargingStarted}},
+			{10, batteryDischarging, []string{types.BatteryChargingStopped, "low:10"}},
+		}},
+		{"no low events while charging", []step{
+			{30, batteryCharging, nil},
+			{15, batteryCharging, nil},
+		}},
+		{"charging stops at a threshold", []step{
+			{70, batteryCharging, nil},
+			{80, batteryNotCharging, []string{types.BatteryChargingStopped}},
+		}},
+		{"full replaces charging stopped", []step{
+			{99, batteryCharging, nil},
+			{100, batteryFull, []string{types.BatteryFull}},
+			{100, batteryFull, nil},
+			{99, batteryDischarging, nil},
+		}},
+		{"plugged in while discharging", []step{
+			{60, batteryDischarging, nil},
+			{60, batteryCharging, []string{types.BatteryChargingStarted}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tracker batteryEventTracker
+			for i, s := range tt.steps {
+				events := tracker.update(types.BatteryInfo{Percentage: s.percentage, State: s.state}, levels)
+				var got []string
+				for _, event := range events {
+					if event.Event == types.BatteryLow {
+						got = append(got, fmt.Sprintf("low:%d", event.Level))
+					} else {
+						got = append(got, event.Event)
+					}
+					if event.Percentage != s.percentage || event.State != s.state {
+						t.Errorf("step %d: event %+v does not carry the current state", i, event)
+					}
+				}
+				if !slices.Equal(got, s.events) {
+					t.Errorf("step %d (%d%% %s): events = %q, want %q", i, s.percentage, s.state, got, s.events)
+				}
+			}
+		})
+	}
+}
+
+func TestBatteryEventGetRefused(t *testing.T) {
+	if got, want := handleGet("battery_event"), "ERROR BATTERY_EVENT is only available with SUB\n"; got != want {
+		t.Errorf("GET BATTERY_EVENT = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+// powerSupplyTree is a fake sysfs with an energy-based and a charge-based
+// battery, an adapter, a wireless mouse and an empty battery bay
+var powerSupplyTree = map[string]string{
+	"class/power_supply/BAT0/uevent": `POWER_SUPPLY_NAME=BAT0
+POWER_SUPPLY_TYPE=Battery
+POWER_SUPPLY_STATUS=Discharging
+POWER_SUPPLY_PRESENT=1
+POWER_SUPPLY_CAPACITY=66
+POWER_SUPPLY_ENERGY_NOW=30000000
+POWER_SUPPLY_ENERGY_FULL=45000000
+POWER_SUPPLY_ENERGY_FULL_DESIGN=50000000
+POWER_SUPPLY_POWER_NOW=10000000
+POWER_SUPPLY_VOLTAGE_NOW=12000000
+POWER_SUPPLY_CYCLE_COUNT=0
+`,
+	"class/power_supply/BAT0/charge_control_end_threshold": "80\n",
+	"class/power_supply/BAT1/uevent": `POWER_SUPPLY_NAME=BAT1
+POWER_SUPPLY_TYPE=Battery
+POWER_SUPPLY_STATUS=Discharging
+POWER_SUPPLY_CHARGE_NOW=1000000
+POWER_SUPPLY_CHARGE_FULL=2000000
+POWER_SUPPLY_CHARGE_FULL_DESIGN=2000000
+POWER_SUPPLY_CURRENT_NOW=-1000000
+POWER_SUPPLY_VOLTAGE_NOW=12000000
+POWER_SUPPLY_VOLTAGE_MIN_DESIGN=11100000
+POWER_SUPPLY_CYCLE_COUNT=42
+`,
+	"class/power_supply/BAT2/uevent": `POWER_SUPPLY_NAME=BAT2
+POWER_SUPPLY_TYPE=Battery
+POWER_SUPPLY_PRESENT=0
+`,
+	"class/power_supply/AC/uevent": `POWER_SUPPLY_NAME=AC
+POWER_SUPPLY_TYPE=Mains
+POWER_SUPPLY_ONLINE=0
+`,
+	"class/power_supply/hidpp_battery_0/uevent": `POWER_SUPPLY_NAME=hidpp_battery_0
+POWER_SUPPLY_TYPE=Battery
+POWER_SUPPLY_SCOPE=Device
+POWER_SUPPLY_STATUS=Discharging
+POWER_SUPPLY_CAPACITY=5
+`,
+}
+
+// resetBatteryRates forgets the smoothed rates of earlier tests
+func resetBatteryRates(t *testing.T) {
+	t.Helper()
+	batteryRates.Lock()
+	batteryRates.m = make(map[string]smoothedRate)
+	batteryRates.Unlock()
+}
+
+func TestReadPowerSupplies(t *testing.T) {
+	resetBatteryRates(t)
+	root := t.TempDir()
+	writeFiles(t, root, powerSupplyTree)
+
+	info, err := readPowerSupplies(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The mouse and the empty bay are skipped
+	if len(info.Batteries) != 2 || info.Batteries[0].Name != "BAT0" || info.Batteries[1].Name != "BAT1" {
+		t.Fatalf("batteries = %+v, want BAT0 and BAT1", info.Batteries)
+	}
+	if len(info.Adapters) != 1 || info.Adapters[0] != (types.ACAdapter{Name: "AC"}) || info.ACOnline {
+		t.Errorf("adapters = %+v, online %v, want AC offline", info.Adapters, info.ACOnline)
+	}
+
+	bat0 := info.Batteries[0]
+	assertFloat(t, "BAT0 energy", bat0.EnergyNow, 30)
+	assertFloat(t, "BAT0 power", bat0.Power, 10)
+	assertOptional(t, "BAT0 time to empty", bat0.TimeToEmpty, ptr(180.0))
+	if bat0.Percentage != 66 {
+		t.Errorf("BAT0 percentage = %d, want 66", bat0.Percentage)
+	}
+	if bat0.Health.CycleCount != nil {
+		t.Errorf("BAT0 cycle count = %d, want nil", *bat0.Health.CycleCount)
+	}
+	assertOptional(t, "BAT0 wear", bat0.Health.Wear, ptr(10.0))
+	if bat0.ChargeEndThreshold == nil || *bat0.ChargeEndThreshold != 80 || bat0.ChargeStartThreshold != nil {
+		t.Errorf("BAT0 thresholds = %v/%v, want -/80", deref(bat0.ChargeStartThreshold), deref(bat0.ChargeEndThreshold))
+	}
+
+	// Charge and current are converted with the design voltage, so the
+	// estimate is charge divided by current
+	bat1 := info.Batteries[1]
+	assertFloat(t, "BAT1 energy", bat1.EnergyNow, 11.1)
+	assertFloat(t, "BAT1 full", bat1.EnergyFull, 22.2)
+	assertFloat(t, "BAT1 power", bat1.Power, 11.1)
+	assertOptional(t, "BAT1 time to empty", bat1.TimeToEmpty, ptr(60.0))
+	if bat1.Percentage != 50 {
+		t.Errorf("BAT1 percentage = %d, want 50", bat1.Percentage)
+	}
+
+	// Weighted by energy: 41.1 of 67.2 Wh, not the average of 66% and 50%
+	total := info.Total
+	if total.Percentage != 61 || total.State != batteryDischarging {
+		t.Errorf("total = %d%% %s, want 61%% Discharging", total.Percentage, total.State)
+	}
+	assertFloat(t, "total power", total.Power, 21.1)
+	assertOptional(t, "total time to empty", total.TimeToEmpty, ptr(41.1/21.1*60))
+	if total.Health != nil {
+		t.Error("total has health information")
+	}
+}
+
+func TestAggregateBatteries(t *testing.T) {
+	tests := []struct {
+		name      string
+		batteries []types.BatteryInfo
+		rates     []float64
+		want      types.BatteryInfo
+	}{
+		{
+			name: "no batteries",
+			want: types.BatteryInfo{State: batteryUnknown},
+		},
+		{
+			name: "weighted by energy",
+			batteries: []types.BatteryInfo{
+				{State: batteryCharging, Percentage: 90, EnergyNow: 18, EnergyFull: 20, Power: 5},
+				{State: batteryCharging, Percentage: 10, EnergyNow: 8, EnergyFull: 80, Power: 15},
+			},
+			rates: []float64{5, 15},
+			want: types.BatteryInfo{
+				State: batteryCharging, Percentage: 26, EnergyNow: 26, EnergyFull: 100, Power: 20,
+				TimeToFull: ptr(74.0 / 20 * 60),
+			},
+		},
+		{
+			name: "only the discharging battery counts",
+			batteries: []types.BatteryInfo{
+				{State: batteryNotCharging, Percentage: 80, EnergyNow: 40, EnergyFull: 50},
+				{State: batteryDischarging, Percentage: 50, EnergyNow: 10, EnergyFull: 20, Power: 6},
+			},
+			rates: []float64{0, 5},
+			want: types.BatteryInfo{
+				State: batteryDischarging, Percentage: 71, EnergyNow: 50, EnergyFull: 70, Power: 6,
+				TimeToEmpty: ptr(50.0 / 5 * 60),
+			},
+		},
+		{
+			name: "all full",
+			batteries: []types.BatteryInfo{
+				{State: batteryFull, Percentage: 100, EnergyNow: 50, EnergyFull: 50},
+				{State: batteryFull, Percentage: 100, EnergyNow: 20, EnergyFull: 20},
+			},
+			rates: []float64{0, 0},
+			want:  types.BatteryInfo{State: batteryFull, Percentage: 100, EnergyNow: 70, EnergyFull: 70, TimeToFull: ptr(0.0)},
+		},
+		{
+			name: "percentages without energy",
+			batteries: []types.BatteryInfo{
+				{State: batteryNotCharging, Percentage: 80},
+				{State: batteryFull, Percentage: 100},
+			},
+			rates: []float64{0, 0},
+			want:  types.BatteryInfo{State: batteryNotCharging, Percentage: 90},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := aggregateBatteries(tt.batteries, tt.rates)
+			if got.State != tt.want.State || got.Percentage != tt.want.Percentage {
+				t.Errorf("total = %d%% %s, want %d%% %s", got.Percentage, got.State, tt.want.Percentage, tt.want.State)
+			}
+			assertFloat(t, "energy", got.EnergyNow, tt.want.EnergyNow)
+			assertFloat(t, "full", got.EnergyFull, tt.want.EnergyFull)
+			assertFloat(t, "power", got.Power, tt.want.Power)
+			assertOptional(t, "time to empty", got.TimeToEmpty, tt.want.TimeToEmpty)
+			assertOptional(t, "time to full", got.TimeToFull, tt.want.TimeToFull)
+		})
+	}
+}
+
+func TestSetTimeEstimates(t *testing.T) {
+	tests := []struct {
+		name            string
+		battery         types.BatteryInfo
+		rate            float64
+		toEmpty, toFull *float64
+	}{
+		{"discharging", types.BatteryInfo{State: batteryDischarging, EnergyNow: 20, EnergyFull: 40}, 10, ptr(120.0), nil},
+		{"charging", types.BatteryInfo{State: batteryCharging, EnergyNow: 20, EnergyFull: 40}, 40, nil, ptr(30.0)},
+		{"full", types.BatteryInfo{State: batteryFull, EnergyNow: 40, EnergyFull: 40}, 0, nil, ptr(0.0)},
+		{"no rate", types.BatteryInfo{State: batteryDischarging, EnergyNow: 20, EnergyFull: 40}, 0, nil, nil},
+		{"negative rate", types.BatteryInfo{State: batteryCharging, EnergyNow: 20, EnergyFull: 40}, -1, nil, nil},
+		{"no capacity", types.BatteryInfo{State: batteryDischarging}, 10, nil, nil},
+		{"not charging", types.BatteryInfo{State: batteryNotCharging, EnergyNow: 20, EnergyFull: 40}, 10, nil, nil},
+		{"unknown", types.BatteryInfo{State: batteryUnknown, EnergyNow: 20, EnergyFull: 40}, 10, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			battery := tt.battery
+			setTimeEstimates(&battery, tt.rate)
+			assertOptional(t, "time to empty", battery.TimeToEmpty, tt.toEmpty)
+			assertOptional(t, "time to full", battery.TimeToFull, tt.toFull)
+		})
+	}
+}
+
+func assertFloat(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+func assertOptional(t *testing.T, name string, got, want *float64) {
+	t.Helper()
+	if got == nil || want == nil {
+		if got != want {
+			t.Errorf("%s = %v, want %v", name, deref(got), deref(want))
+		}
+		return
+	}
+	assertFloat(t, name, *got, *want)
+}
+
+func TestConfiguredBatterySysRoot(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     string
+	}{
+		{"default", "", "/sys"},
+		{"sys_root", "[battery]\nsys_root = \"/tmp/sys\"\n", "/tmp/sys"},
+		{"legacy battery_path", "[system]\nbattery_path = \"/tmp/sys/class/power_supply/BAT1\"\n", "/tmp/sys"},
+		{"legacy battery_path with slash", "[system]\nbattery_path = \"/sys/class/power_supply/BAT0/\"\n", "/sys"},
+		{"sys_root wins", "[system]\nbattery_path = \"/tmp/sys/class/power_supply/BAT1\"\n[battery]\nsys_root = \"/other\"\n", "/other"},
+		{"unusable battery_path", "[system]\nbattery_path = \"/tmp/BAT0\"\n", "/sys"},
+	}
+	for _, tt := range tests {
+		values, err := parseTOML(tt.document)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := configuredBatterySysRoot(&Config{values: values}); got != tt.want {
+			t.Errorf("%s: sys root = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,11 +1,11 @@
 package main
 
 import (
-	"log"
-	"os"
-	"os/signal"
+	"context"
+	"errors"
+	"fmt"
 	"sync"
-	"syscall"
+	"time"
 
 	"github.com/GcZuRi1886/system-info-provider/types"
 	"github.com/godbus/dbus/v5"
@@ -16,6 +16,40 @@ var BluetoothConnection struct {
 	sync.Mutex
 }
 
+// bluetoothCollector publishes BlueZ adapter and device state
+type bluetoothCollector struct{}
+
+func init() {
+	RegisterCollector(&bluetoothCollector{})
+}
+
+// Name returns the data type name
+func (b *bluetoothCollector) Name() string {
+	return "bluetooth"
+}
+
+// Interval returns zero, Bluetooth updates are event driven
+func (b *bluetoothCollector) Interval() time.Duration {
+	return 0
+}
+
+// Snapshot returns the current Bluetooth state
+func (b *bluetoothCollector) Snapshot() (any, error) {
+	if err := connectBluetooth(); err != nil {
+		return nil, err
+	}
+	var bluetoothDataWrapper = initBluetoothDataWrapper()
+	if err := loadInitialBluezState(bluetoothDataWrapper.Data.(*types.BluetoothInfo)); err != nil {
+		return nil, err
+	}
+	return bluetoothDataWrapper.Data, nil
+}
+
+// Start listens for BlueZ property changes until ctx is cancelled
+func (b *bluetoothCollector) Start(ctx context.Context, emit EmitFunc) error {
+	return listenForBluetoothChanges(ctx, emit)
+}
+
 func initBluetoothDataWrapper() types.Wrapper {
 	var bluetoothDataWrapper types.Wrapper
 	bluetoothDataWrapper.Type = "bluetooth"
@@ -26,43 +60,73 @@ func initBluetoothDataWrapper() types.Wrapper {
 	return bluetoothDataWrapper
 }
 
-func listenForBluetoothChanges(emit func(dataType string, data any)) {
+// connectBluetooth connects to the system bus unless a live connection exists
+func connectBluetooth() error {
+	BluetoothConnection.Lock()
+	defer BluetoothConnection.Unlock()
+	if BluetoothConnection.Conn != nil && BluetoothConnection.Conn.Connected() {
+		return nil
+	}
 	conn, err := dbus.SystemBus()
 	if err != nil {
-		log.Fatalf("Failed to connect to system bus: %v", err)
+		return fmt.Errorf("failed to connect to system bus: %w", err)
 	}
 	BluetoothConnection.Conn = conn
-	
+	return nil
+}
+
+func listenForBluetoothChanges(ctx context.Context, emit EmitFunc) error {
+	if err := connectBluetooth(); err != nil {
+		return err
+	}
+
 	var bluetoothDataWrapper = initBluetoothDataWrapper()
 
 	// Initial state
-	loadInitialBluezState(bluetoothDataWrapper.Data.(*types.BluetoothInfo))
+	if err := loadInitialBluezState(bluetoothDataWrapper.Data.(*types.BluetoothInfo)); err != nil {
+		return err
+	}
 	emit(bluetoothDataWrapper.Type, bluetoothDataWrapper)
 
 	BluetoothConnection.Lock()
-	defer BluetoothConnection.Unlock()
-	// Add a signal match rule for BlueZ Property changes
-	rule := "type='signal',sender='org.bluez',interface='org.freedesktop.DBus.Properties',member='PropertiesChanged'"
-	call := BluetoothConnection.Conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, rule)
-	if call.Err != nil {
-		log.Fatalf("Failed to add D-Bus match: %v", call.Err)
+	// Add signal match rules for BlueZ Property changes and BlueZ restarts
+	rules := []string{
+		"type='signal',sender='org.bluez',interface='org.freedesktop.DBus.Properties',member='PropertiesChanged'",
+		"type='signal',interface='org.freedesktop.DBus',member='NameOwnerChanged',arg0='org.bluez'",
+	}
+	for _, rule := range rules {
+		call := BluetoothConnection.Conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, rule)
+		if call.Err != nil {
+			BluetoothConnection.Unlock()
+			return fmt.Errorf("failed to add D-Bus match: %w", call.Err)
+		}
 	}
 
 	// Channel to receive D-Bus signals
 	c := make(chan *dbus.Signal, 10)
-	BluetoothConnection.Conn.Signal(c)
+	conn := BluetoothConnection.Conn
+	conn.Signal(c)
 	BluetoothConnection.Unlock()
 
-	// Handle system interrupts to exit cleanly
-	sigc := make(chan os.Signal, 1)
-	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	defer func() {
+		conn.RemoveSignal(c)
+		for _, rule := range rules {
+			conn.BusObject().Call("org.freedesktop.DBus.RemoveMatch", 0, rule)
+		}
+	}()
 
 	for {
 		select {
-		case sig := <-sigc:
-			log.Printf("Exiting on signal: %v", sig)
-			return
-		case signalMsg := <-c:
+		case <-ctx.Done():
+			return ctx.Err()
+		case signalMsg, ok := <-c:
+			if !ok {
+				return errors.New("system bus connection closed")
+			}
+			if signalMsg.Name == "org.freedesktop.DBus.NameOwnerChanged" {
+				// BlueZ stopped or restarted; the supervisor reloads the state
+				return errors.New("org.bluez left the system bus")
+			}
 			handleSignal(signalMsg, bluetoothDataWrapper.Data.(*types.BluetoothInfo))
 			emit(bluetoothDataWrapper.Type, bluetoothDataWrapper)
 		}
@@ -70,7 +134,7 @@ func listenForBluetoothChanges(emit func(dataType string, data any)) {
 }
 
 // Load initial device + adapter state
-func loadInitialBluezState(info *types.BluetoothInfo) {
+func loadInitialBluezState(info *types.BluetoothInfo) error {
 	BluetoothConnection.Lock()
 	defer BluetoothConnection.Unlock()
 	obj := BluetoothConnection.Conn.Object("org.bluez", dbus.ObjectPath("/"))
@@ -78,7 +142,7 @@ func loadInitialBluezState(info *types.BluetoothInfo) {
 
 	err := obj.Call("org.freedesktop.DBus.ObjectManager.GetManagedObjects", 0).Store(&managed)
 	if err != nil {
-		log.Fatalf("Failed to get managed objects: %v", err)
+		return fmt.Errorf("failed to get managed objects: %w", err)
 	}
 
 	for path, ifaces := range managed {
@@ -99,6 +163,7 @@ func loadInitialBluezState(info *types.BluetoothInfo) {
 			info.Devices[devPath] = d
 		}
 	}
+	return nil
 }
 
 // Handle BlueZ property change events
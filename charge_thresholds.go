@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Sysfs attributes of the charge thresholds, in percent
+const (
+	chargeStartAttr = "charge_control_start_threshold"
+	chargeEndAttr   = "charge_control_end_threshold"
+)
+
+// chargeThresholds are the limits a battery charges between. A negative
+// value leaves the threshold unchanged.
+type chargeThresholds struct {
+	start, end int
+}
+
+// Thresholds set with SET BATTERY, keyed by battery name. They take
+// precedence over the configured thresholds until the daemon exits.
+var chargeOverrides = struct {
+	sync.Mutex
+	m map[string]chargeThresholds
+}{m: make(map[string]chargeThresholds)}
+
+// validate checks the range of the thresholds
+func (t chargeThresholds) validate() error {
+	if t.start > 99 {
+		return fmt.Errorf("start threshold %d out of range 0-99", t.start)
+	}
+	if t.end == 0 || t.end > 100 {
+		return fmt.Errorf("end threshold %d out of range 1-100", t.end)
+	}
+	if t.start >= 0 && t.end >= 0 && t.start >= t.end {
+		return fmt.Errorf("start threshold %d must be below end threshold %d", t.start, t.end)
+	}
+	return nil
+}
+
+// configuredThresholds returns the thresholds of [battery]
+func configuredThresholds(cfg *Config) chargeThresholds {
+	settings := cfg.Section("battery")
+	return chargeThresholds{
+		start: settings.Int("charge_start_threshold", -1),
+		end:   settings.Int("charge_end_threshold", -1),
+	}
+}
+
+// Set changes the charge thresholds of a battery, or of all batteries:
+//
+//	<battery|all> <start|-> <end|->
+//
+// "-" leaves a threshold unchanged, e.g. on batteries that only support an
+// end threshold. The values are re-applied after suspend.
+func (b *batteryCollector) Set(args []string) error {
+	if len(args) != 3 {
+		return errors.New("usage: SET BATTERY <battery|all> <start|-> <end|->")
+	}
+	var thresholds chargeThresholds
+	for i, value := range []*int{&thresholds.start, &thresholds.end} {
+		if args[i+1] == "-" {
+			*value = -1
+			continue
+		}
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid threshold %q", args[i+1])
+		}
+		*value = n
+	}
+	if thresholds.start < 0 && thresholds.end < 0 {
+		return errors.New("no threshold to set")
+	}
+	if err := thresholds.validate(); err != nil {
+		return err
+	}
+
+	dirs, err := chargeControlDirs(batterySysRoot())
+	if err != nil {
+		return err
+	}
+	if args[0] != "all" {
+		dir := filepath.Join(batterySysRoot(), "class", "power_supply", args[0])
+		if !slices.Contains(dirs, dir) {
+			return fmt.Errorf("battery %s does not support charge thresholds", args[0])
+		}
+		dirs = []string{dir}
+	}
+	if len(dirs) == 0 {
+		return errors.New("no battery supports charge thresholds")
+	}
+
+	for _, dir := range dirs {
+		if err := writeChargeThresholds(dir, thresholds); err != nil {
+			return err
+		}
+		chargeOverrides.Lock()
+		chargeOverrides.m[filepath.Base(dir)] = thresholds
+		chargeOverrides.Unlock()
+	}
+	return nil
+}
+
+// applyChargeThresholds writes the configured thresholds, or the ones set
+// with SET BATTERY, to every battery that supports them. Batteries without
+// any are left alone.
+func applyChargeThresholds(cfg *Config) {
+	configured := configuredThresholds(cfg)
+	if err := configured.validate(); err != nil {
+		log.Printf("Ignoring charge thresholds in [battery]: %v", err)
+		configured = chargeThresholds{-1, -1}
+	}
+
+	dirs, err := chargeControlDirs(configuredBatterySysRoot(cfg))
+	if err != nil {
+		log.Printf("Error listing batteries: %v", err)
+		return
+	}
+	for _, dir := range dirs {
+		chargeOverrides.Lock()
+		thresholds, ok := chargeOverrides.m[filepath.Base(dir)]
+		chargeOverrides.Unlock()
+		if !ok {
+			thresholds = configured
+		}
+		if thresholds.start < 0 && thresholds.end < 0 {
+			continue
+		}
+		if err := writeChargeThresholds(dir, thresholds); err != nil {
+			log.Printf("Error applying charge thresholds: %v", err)
+		}
+	}
+}
+
+// chargeControlDirs returns the batteries with an end threshold attribute
+func chargeControlDirs(sysRoot string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(sysRoot, "class", "power_supply", "*", chargeEndAttr))
+	dirs := make([]string, len(paths))
+	for i, path := range paths {
+		dirs[i] = filepath.Dir(path)
+	}
+	return dirs, err
+}
+
+// writeChargeThresholds writes the thresholds of the battery in dir
+func writeChargeThresholds(dir string, thresholds chargeThresholds) error {
+	name := filepath.Base(dir)
+	writes, err := chargeThresholdWrites(dir, thresholds)
+	if err != nil {
+		return err
+	}
+	for _, w := range writes {
+		if !isWritable(w.path) {
+			return fmt.Errorf("%s of battery %s is not writable by the daemon", filepath.Base(w.path), name)
+		}
+		if err := writeSysfsInt(w.path, w.value); err != nil {
+			return fmt.Errorf("set %s of battery %s: %w", filepath.Base(w.path), name, err)
+		}
+	}
+	return nil
+}
+
+// sysfsWrite is a value to write to a sysfs attribute
+type sysfsWrite struct {
+	path  string
+	value int
+}
+
+// chargeThresholdWrites returns the attribute writes that set the thresholds
+// of the battery in dir. A threshold that is left unchanged keeps its
+// current value, which the other one is validated against. Drivers reject a
+// start threshold above the current end threshold and vice versa, so the
+// order depends on the direction of the change.
+func chargeThresholdWrites(dir string, thresholds chargeThresholds) ([]sysfsWrite, error) {
+	name := filepath.Base(dir)
+	startPath, endPath := filepath.Join(dir, chargeStartAttr), filepath.Join(dir, chargeEndAttr)
+	currentStart, hasStart := readSysfsIntOK(startPath)
+	currentEnd, hasEnd := readSysfsIntOK(endPath)
+
+	if thresholds.start >= 0 {
+		if _, err := os.Stat(startPath); err != nil {
+			return nil, fmt.Errorf("battery %s has no start threshold", name)
+		}
+	}
+
+	combined := thresholds
+	if combined.start < 0 && hasStart {
+		combined.start = currentStart
+	}
+	if combined.end < 0 && hasEnd {
+		combined.end = currentEnd
+	}
+	if err := combined.validate(); err != nil {
+		return nil, fmt.Errorf("battery %s: %w", name, err)
+	}
+
+	var writes []sysfsWrite
+	if thresholds.start >= 0 {
+		writes = append(writes, sysfsWrite{startPath, thresholds.start})
+	}
+	if thresholds.end >= 0 {
+		end := sysfsWrite{endPath, thresholds.end}
+		if hasEnd && thresholds.start >= currentEnd {
+			writes = append([]sysfsWrite{end}, writes...)
+		} else {
+			writes = append(writes, end)
+		}
+	}
+	return writes, nil
+}
+
+// keepChargeThresholds applies the charge thresholds now, on every config
+// reload and after every resume from suspend, when firmware may have reset
+// them, until ctx is cancelled
+func keepChargeThresholds(ctx context.Context) {
+	applyChargeThresholds(currentConfig())
+	onConfigLoad(applyChargeThresholds)
+
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		log.Printf("Charge thresholds will not be re-applied after suspend: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	rule := "type='signal',interface='org.freedesktop.login1.Manager',member='PrepareForSleep'"
+	if call := conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, rule); call.Err != nil {
+		log.Printf("Charge thresholds will not be re-applied after suspend: %v", call.Err)
+		return
+	}
+	c := make(chan *dbus.Signal, 4)
+	conn.Signal(c)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case signalMsg, ok := <-c:
+			if !ok {
+				log.Printf("System bus connection closed, charge thresholds will not be re-applied after suspend")
+				return
+			}
+			// PrepareForSleep(false) is sent after resuming
+			if len(signalMsg.Body) == 1 && signalMsg.Body[0] == false {
+				applyChargeThresholds(currentConfig())
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestChargeThresholdsValidate(t *testing.T) {
+	tests := []struct {
+		start, end int
+		valid      bool
+	}{
+		{40, 80, true},
+		{0, 100, true},
+		{-1, 80, true},
+		{40, -1, true},
+		{-1, -1, true},
+		{99, 100, true},
+		{100, -1, false},
+		{-1, 0, false},
+		{-1, 101, false},
+		{80, 80, false},
+		{90, 80, false},
+	}
+	for _, tt := range tests {
+		err := chargeThresholds{tt.start, tt.end}.validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("validate(%d, %d) = %v, want valid %v", tt.start, tt.end, err, tt.valid)
+		}
+	}
+}
+
+func TestChargeThresholdWrites(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"BAT0/" + chargeStartAttr: "70\n",
+		"BAT0/" + chargeEndAttr:   "80\n",
+		"BAT1/" + chargeEndAttr:   "80\n",
+	})
+	bat0, bat1 := filepath.Join(root, "BAT0"), filepath.Join(root, "BAT1")
+	start, end := filepath.Join(bat0, chargeStartAttr), filepath.Join(bat0, chargeEndAttr)
+
+	tests := []struct {
+		name       string
+		dir        string
+		thresholds chargeThresholds
+		want       []sysfsWrite
+	}{
+		{"lower both", bat0, chargeThresholds{20, 50}, []sysfsWrite{{start, 20}, {end, 50}}},
+		{"raise start past current end", bat0, chargeThresholds{85, 95}, []sysfsWrite{{end, 95}, {start, 85}}},
+		{"start equal to current end", bat0, chargeThresholds{80, 90}, []sysfsWrite{{end, 90}, {start, 80}}},
+		{"start only", bat0, chargeThresholds{40, -1}, []sysfsWrite{{start, 40}}},
+		{"end only", bat0, chargeThresholds{-1, 90}, []sysfsWrite{{end, 90}}},
+		{"end only battery", bat1, chargeThresholds{-1, 60}, []sysfsWrite{{filepath.Join(bat1, chargeEndAttr), 60}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := chargeThresholdWrites(tt.dir, tt.thresholds)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("writes = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("writes = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+
+	invalid := []struct {
+		name       string
+		dir        string
+		thresholds chargeThresholds
+	}{
+		{"start on battery without one", bat1, chargeThresholds{40, 80}},
+		{"end only below current start", bat0, chargeThresholds{-1, 60}},
+		{"start only above current end", bat0, chargeThresholds{85, -1}},
+		{"start only equal to current end", bat0, chargeThresholds{80, -1}},
+	}
+	for _, tt := range invalid {
+		if writes, err := chargeThresholdWrites(tt.dir, tt.thresholds); err == nil {
+			t.Errorf("%s: writes = %v, want an error", tt.name, writes)
+		}
+	}
+}
+
+func TestWriteChargeThresholds(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"BAT0/" + chargeStartAttr: "70\n",
+		"BAT0/" + chargeEndAttr:   "80\n",
+	})
+	dir := filepath.Join(root, "BAT0")
+
+	if err := writeChargeThresholds(dir, chargeThresholds{85, 95}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readSysfsInt(filepath.Join(dir, chargeStartAttr)); got != 85 {
+		t.Errorf("start = %d, want 85", got)
+	}
+	if got := readSysfsInt(filepath.Join(dir, chargeEndAttr)); got != 95 {
+		t.Errorf("end = %d, want 95", got)
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+// EmitFunc publishes an update of the given data type
+type EmitFunc func(dataType string, data any)
+
+// Collector is a data source published under a single type name.
+// Collectors register themselves with RegisterCollector from an init function;
+// the CLI and the socket server accept every registered name.
+type Collector interface {
+	// Name returns the data type published by the collector, e.g. "system"
+	Name() string
+	// Interval returns the polling interval, or zero for event-driven collectors
+	Interval() time.Duration
+	// Snapshot returns the current state of the data source. It may return
+	// partial data together with an error.
+	Snapshot() (any, error)
+	// Start publishes updates through emit until ctx is cancelled
+	Start(ctx context.Context, emit EmitFunc) error
+}
+
+// Setter is implemented by collectors that accept "SET <TYPE> <args...>"
+// commands from socket clients
+type Setter interface {
+	// Set applies a command; the returned error is sent to the client
+	Set(args []string) error
+}
+
+// Sampler is implemented by collectors that report rates over the time
+// between two reads. Only the poll loop takes new samples with Sample;
+// Snapshot returns the last sample, so GET and the initial state sent to new
+// subscribers do not shorten the window of the next update.
+type Sampler interface {
+	// Sample reads the data source and returns the data since the previous sample
+	Sample() (any, error)
+}
+
+// OnDemand is implemented by collectors that are too expensive to stream.
+// They are only read with "GET <TYPE>", SUB is refused and the CLI prints a
+// single snapshot.
+type OnDemand interface {
+	OnDemand()
+}
+
+// SubscribeOnly is implemented by collectors that publish events and have
+// no current state. They are only available with "SUB <TYPE>"; GET is
+// refused.
+type SubscribeOnly interface {
+	SubscribeOnly()
+}
+
+// Registered collectors, keyed by lower-case name
+var registry = struct {
+	sync.RWMutex
+	m       map[string]Collector
+	aliases map[string]string // legacy name → collector name
+}{m: make(map[string]Collector), aliases: make(map[string]string)}
+
+// RegisterCollector makes a collector available under its name
+func RegisterCollector(c Collector) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.m[strings.ToLower(c.Name())] = c
+}
+
+// UnregisterCollector removes the collector with the given name
+func UnregisterCollector(name string) {
+	registry.Lock()
+	defer registry.Unlock()
+	delete(registry.m, strings.ToLower(name))
+}
+
+// registerAlias makes an existing collector also available under alias
+func registerAlias(alias, name string) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.aliases[strings.ToLower(alias)] = strings.ToLower(name)
+}
+
+// lookupCollector finds a collector by name or alias, ignoring case
+func lookupCollector(name string) (Collector, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	name = strings.ToLower(name)
+	if target, ok := registry.aliases[name]; ok {
+		name = target
+	}
+	c, ok := registry.m[name]
+	return c, ok
+}
+
+// registeredCollectors returns all collectors sorted by name
+func registeredCollectors() []Collector {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	collectors := make([]Collector, 0, len(registry.m))
+	for _, c := range registry.m {
+		collectors = append(collectors, c)
+	}
+	slices.SortFunc(collectors, func(a, b Collector) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return collectors
+}
+
+// definedCollector is a collector created from an entry of an array of
+// tables in the config, such as [[plugin]]
+type definedCollector interface {
+	Collector
+	// definition describes the configuration the collector was built from
+	definition() string
+}
+
+// Collectors defined in the config, keyed by table name and collector name
+var defined = struct {
+	sync.Mutex
+	m map[string]map[string]definedCollector
+}{m: make(map[string]map[string]definedCollector)}
+
+// registerDefined registers a collector for every enabled entry of the array
+// of tables kind and unregisters the ones that are no longer configured.
+// Collectors whose definition is unchanged are kept as they are.
+func registerDefined(cfg *Config, kind string, build func(Section) (definedCollector, error)) {
+	defined.Lock()
+	defer defined.Unlock()
+
+	current := defined.m[kind]
+	if current == nil {
+		current = make(map[string]definedCollector)
+		defined.m[kind] = current
+	}
+
+	configured := make(map[string]bool)
+	for _, section := range cfg.Sections(kind) {
+		c, err := build(section)
+		if err != nil {
+			log.Printf("Skipping %s: %v", kind, err)
+			continue
+		}
+		if !section.Enabled() {
+			continue
+		}
+		name := c.Name()
+		if existing, ok := lookupCollector(name); ok && current[name] != existing {
+			log.Printf("Skipping %s %s: name is already used by another data type", kind, name)
+			continue
+		}
+		configured[name] = true
+
+		if old, ok := current[name]; ok && old.definition() == c.definition() {
+			continue
+		}
+		current[name] = c
+		RegisterCollector(c)
+	}
+
+	for name := range current {
+		if !configured[name] {
+			UnregisterCollector(name)
+			delete(current, name)
+		}
+	}
+}
+
+// jsonValue returns s if it is valid JSON and s encoded as a JSON string otherwise
+func jsonValue(s string) json.RawMessage {
+	value := json.RawMessage(s)
+	if !json.Valid(value) {
+		value, _ = json.Marshal(s)
+	}
+	return value
+}
+
+// wrapData wraps collector data in the envelope sent to clients
+func wrapData(c Collector, data any) types.Wrapper {
+	return types.Wrapper{Type: c.Name(), Data: data}
+}
+
+// pollCollector emits a snapshot of c every Interval until ctx is cancelled.
+// Polled collectors use it as their Start implementation. A snapshot that
+// returns both data and an error is published and reported as degraded.
+func pollCollector(ctx context.Context, c Collector, emit EmitFunc) error {
+	for {
+		publishSample(c, emit)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(c.Interval()):
+		}
+	}
+}
+
+// publishSnapshot emits the current data of a collector and reports its
+// error, if any
+func publishSnapshot(c Collector, emit EmitFunc) {
+	publish(c, emit, c.Snapshot)
+}
+
+// publishSample is publishSnapshot for polling: collectors implementing
+// Sampler take a new sample
+func publishSample(c Collector, emit EmitFunc) {
+	if s, ok := c.(Sampler); ok {
+		publish(c, emit, s.Sample)
+		return
+	}
+	publishSnapshot(c, emit)
+}
+
+func publish(c Collector, emit EmitFunc, read func() (any, error)) {
+	data, err := read()
+	if data != nil {
+		emit(c.Name(), wrapData(c, data))
+	}
+	if err != nil {
+		reportCollectorError(c.Name(), err)
+	}
+}
+
+// A collector started in socket mode
+type runningCollector struct {
+	collector Collector
+	section   Section // config section the collector was started with
+	cancel    context.CancelFunc
+	idle      *time.Timer // pending stop after the last subscriber left
+}
+
+// Collectors in socket mode. A collector runs only while at least one
+// client is subscribed to its type, so nothing is polled without clients.
+var running = struct {
+	sync.Mutex
+	ctx    context.Context
+	emit   EmitFunc
+	m      map[string]*runningCollector // running collectors, keyed by name
+	wanted map[string]bool              // names with at least one subscriber
+	wg     sync.WaitGroup               // supervisors of all started collectors
+}{m: make(map[string]*runningCollector), wanted: make(map[string]bool)}
+
+// initCollectors sets the context and emit function used for collectors
+// started on demand
+func initCollectors(ctx context.Context, emit EmitFunc) {
+	running.Lock()
+	defer running.Unlock()
+	running.ctx = ctx
+	running.emit = emit
+}
+
+// acquireCollector is called when a type gets its first subscriber. It
+// starts the collector unless it is running already and reports whether
+// it was started.
+func acquireCollector(c Collector) bool {
+	running.Lock()
+	defer running.Unlock()
+
+	name := c.Name()
+	running.wanted[name] = true
+	if r, ok := running.m[name]; ok {
+		if r.idle != nil {
+			r.idle.Stop()
+			r.idle = nil
+		}
+		return false
+	}
+	section := currentConfig().Section(name)
+	if !section.Enabled() {
+		return false
+	}
+	startCollectorLocked(c, section)
+	return true
+}
+
+// releaseCollector is called when the last subscriber of a type is gone.
+// The collector keeps running for the configured idle timeout so clients
+// that reconnect right away do not restart it.
+func releaseCollector(name string) {
+	running.Lock()
+	defer running.Unlock()
+
+	delete(running.wanted, name)
+	r, ok := running.m[name]
+	if !ok || r.idle != nil {
+		return
+	}
+	timeout := currentConfig().Section("general").Duration("idle_timeout", 10*time.Second)
+	r.idle = time.AfterFunc(timeout, func() {
+		running.Lock()
+		defer running.Unlock()
+		if running.m[name] == r && !running.wanted[name] {
+			log.Printf("Stopping idle collector %s", name)
+			r.cancel()
+			delete(running.m, name)
+		}
+	})
+}
+
+// startCollectorLocked starts c with the settings in section; running must
+// be locked
+func startCollectorLocked(c Collector, section Section) {
+	ctx, cancel := context.WithCancel(running.ctx)
+	running.m[c.Name()] = &runningCollector{collector: c, section: section, cancel: cancel}
+	emit := running.emit
+	running.wg.Go(func() {
+		superviseCollector(ctx, c, emit)
+	})
+}
+
+// waitCollectors waits until all collectors started in socket mode have
+// stopped after the context passed to initCollectors was cancelled
+func waitCollectors() {
+	running.wg.Wait()
+}
+
+// syncCollectors applies a new config to the running collectors: disabled,
+// unregistered and replaced collectors are stopped, and collectors with
+// subscribers are (re)started if they are enabled. Collectors whose section
+// changed are restarted, since some only read their settings in Start.
+func syncCollectors(cfg *Config) {
+	running.Lock()
+	defer running.Unlock()
+	if running.ctx == nil {
+		return
+	}
+
+	for name, r := range running.m {
+		c, ok := lookupCollector(name)
+		section := cfg.Section(name)
+		if !ok || c != r.collector || !section.Enabled() || !reflect.DeepEqual(section, r.section) {
+			log.Printf("Stopping collector %s", name)
+			if r.idle != nil {
+				r.idle.Stop()
+			}
+			r.cancel()
+			delete(running.m, name)
+		}
+	}
+
+	for name := range running.wanted {
+		c, ok := lookupCollector(name)
+		section := cfg.Section(name)
+		if _, isRunning := running.m[name]; isRunning || !ok || !section.Enabled() {
+			continue
+		}
+		startCollectorLocked(c, section)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// startCounter is a collector that counts how often it was started
+type startCounter struct {
+	starts chan Section
+}
+
+func (s *startCounter) Name() string            { return "startcounter" }
+func (s *startCounter) Interval() time.Duration { return 0 }
+func (s *startCounter) Snapshot() (any, error)  { return nil, nil }
+
+func (s *startCounter) Start(ctx context.Context, emit EmitFunc) error {
+	s.starts <- currentConfig().Section(s.Name())
+	<-ctx.Done()
+	return nil
+}
+
+func TestSyncCollectorsRestartsChangedSections(t *testing.T) {
+	c := &startCounter{starts: make(chan Section, 4)}
+	RegisterCollector(c)
+	t.Cleanup(func() { UnregisterCollector(c.Name()) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	initCollectors(ctx, func(string, any) {})
+	t.Cleanup(func() { initCollectors(nil, nil) })
+
+	expectStart := func(want string) {
+		t.Helper()
+		select {
+		case section := <-c.starts:
+			if got := section.String("mode", ""); got != want {
+				t.Errorf("started with mode %q, want %q", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("collector was not started with mode %q", want)
+		}
+	}
+	expectNoStart := func() {
+		t.Helper()
+		select {
+		case section := <-c.starts:
+			t.Errorf("collector restarted with %v", section)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	useConfig(t, "[startcounter]\nmode = \"a\"\n")
+	if !acquireCollector(c) {
+		t.Fatal("collector was not started")
+	}
+	t.Cleanup(func() {
+		running.Lock()
+		running.m[c.Name()].cancel()
+		delete(running.m, c.Name())
+		delete(running.wanted, c.Name())
+		running.Unlock()
+	})
+	expectStart("a")
+
+	// Unrelated changes keep the collector running
+	useConfig(t, "[startcounter]\nmode = \"a\"\n[other]\nx = 1\n")
+	syncCollectors(currentConfig())
+	expectNoStart()
+
+	useConfig(t, "[startcounter]\nmode = \"b\"\n")
+	syncCollectors(currentConfig())
+	expectStart("b")
+
+	useConfig(t, "[startcounter]\nmode = \"b\"\nenabled = false\n")
+	syncCollectors(currentConfig())
+	expectNoStart()
+	running.Lock()
+	_, isRunning := running.m[c.Name()]
+	running.Unlock()
+	if isRunning {
+		t.Error("disabled collector is still running")
+	}
+
+	useConfig(t, "[startcounter]\nmode = \"b\"\n")
+	syncCollectors(currentConfig())
+	expectStart("b")
+}
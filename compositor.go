@@ -25,21 +25,37 @@ func (c Compositor) String() string {
 }
 
 // DetectCompositor checks environment variables and available tools to determine
-// which Wayland compositor is currently running
+// which Wayland compositor is currently running. Compositors are tried in the
+// order given by the workspace "compositors" setting.
 func DetectCompositor() Compositor {
-	// Check for Hyprland first via its instance signature
-	if sig := os.Getenv("HYPRLAND_INSTANCE_SIGNATURE"); sig != "" {
-		return CompositorHyprland
-	}
-
-	// Check for Mango WC by looking for the mmsg tool
-	if _, err := exec.LookPath("mmsg"); err == nil {
-		// Verify mmsg is responsive (mango is running)
-		cmd := exec.Command("mmsg", "-g", "-t")
-		if err := cmd.Run(); err == nil {
-			return CompositorMango
+	order := currentConfig().Section("workspace").Strings("compositors", []string{"hyprland", "mango"})
+	for _, name := range order {
+		switch name {
+		case "hyprland":
+			if isHyprlandRunning() {
+				return CompositorHyprland
+			}
+		case "mango":
+			if isMangoRunning() {
+				return CompositorMango
+			}
 		}
 	}
 
 	return CompositorUnknown
 }
+
+// isHyprlandRunning checks for Hyprland via its instance signature
+func isHyprlandRunning() bool {
+	return os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != ""
+}
+
+// isMangoRunning checks for Mango WC by looking for the mmsg tool
+func isMangoRunning() bool {
+	if _, err := exec.LookPath("mmsg"); err != nil {
+		return false
+	}
+	// Verify mmsg is responsive (mango is running)
+	cmd := exec.Command("mmsg", "-g", "-t")
+	return cmd.Run() == nil
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config holds the settings read from the TOML config file.
+// Top-level tables configure one collector each, e.g. [system] or [bluetooth],
+// and [general] holds daemon-wide settings.
+type Config struct {
+	path   string
+	values map[string]any
+}
+
+// Section is a single table of the config file
+type Section map[string]any
+
+// Currently active configuration, swapped on SIGHUP
+var config = struct {
+	sync.RWMutex
+	c *Config
+}{c: &Config{values: make(map[string]any)}}
+
+// currentConfig returns the active configuration
+func currentConfig() *Config {
+	config.RLock()
+	defer config.RUnlock()
+	return config.c
+}
+
+// configPath returns $XDG_CONFIG_HOME/system-info-provider/config.toml,
+// falling back to ~/.config when XDG_CONFIG_HOME is unset
+func configPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "system-info-provider", "config.toml")
+}
+
+// readConfig parses the config file at path. A missing file yields an empty
+// config so that every setting falls back to its default.
+func readConfig(path string) (*Config, error) {
+	cfg := &Config{path: path, values: make(map[string]any)}
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	values, err := parseTOML(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	cfg.values = values
+	return cfg, nil
+}
+
+// parseTOML decodes a TOML document into nested maps. Integers are int64,
+// arrays []any and tables map[string]any.
+func parseTOML(document string) (map[string]any, error) {
+	values := make(map[string]any)
+	if _, err := toml.Decode(document, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// loadConfig reads the config file, makes it the active configuration and
+// runs the load hooks. On error the previous configuration stays active.
+func loadConfig() error {
+	cfg, err := readConfig(configPath())
+	if err != nil {
+		return err
+	}
+
+	config.Lock()
+	config.c = cfg
+	config.Unlock()
+
+	configHooks.Lock()
+	hooks := configHooks.fns
+	configHooks.Unlock()
+	for _, fn := range hooks {
+		fn(cfg)
+	}
+	return nil
+}
+
+// reloadConfig re-reads the config file on SIGHUP
+func reloadConfig() {
+	old := currentConfig()
+	if err := loadConfig(); err != nil {
+		log.Printf("Config reload failed, keeping previous config: %v", err)
+		return
+	}
+	cfg := currentConfig()
+	log.Printf("Reloaded config from %s", cfg.path)
+
+	if old.SocketPath() != cfg.SocketPath() {
+		log.Printf("socket_path changed to %s; restart the daemon to apply it", cfg.SocketPath())
+	}
+	for _, key := range []string{"socket_group", "socket_mode"} {
+		if !reflect.DeepEqual(old.Section("general")[key], cfg.Section("general")[key]) {
+			log.Printf("%s changed; restart the daemon to apply it", key)
+		}
+	}
+}
+
+// Functions called after every successful load
+var configHooks struct {
+	sync.Mutex
+	fns []func(*Config)
+}
+
+// onConfigLoad registers fn to be called whenever a config is loaded, at
+// startup and on every reload. Hooks run in registration order, so hooks
+// registered from init functions run before the ones registered by main.
+func onConfigLoad(fn func(*Config)) {
+	configHooks.Lock()
+	defer configHooks.Unlock()
+	configHooks.fns = append(configHooks.fns, fn)
+}
+
+// Section returns the table with the given name, or an empty section
+func (c *Config) Section(name string) Section {
+	if s, ok := c.values[name].(map[string]any); ok {
+		return s
+	}
+	return Section{}
+}
+
+// Sections returns the tables of an array of tables, e.g. [[plugin]]
+func (c *Config) Sections(name string) []Section {
+	var sections []Section
+	switch values := c.values[name].(type) {
+	case []map[string]any:
+		for _, v := range values {
+			sections = append(sections, v)
+		}
+	case []any:
+		// An array of inline tables, e.g. plugin = [{ name = "a" }]
+		for _, v := range values {
+			if s, ok := v.(map[string]any); ok {
+				sections = append(sections, s)
+			}
+		}
+	}
+	return sections
+}
+
+// SocketPath returns the path of the Unix socket used in socket mode
+func (c *Config) SocketPath() string {
+	return c.Section("general").String("socket_path", "/tmp/system-info-provider.sock")
+}
+
+// Enabled reports whether the collector configured by this section is enabled
+func (s Section) Enabled() bool {
+	return s.Bool("enabled", true)
+}
+
+// String returns the string value of key, or def if unset or not a string
+func (s Section) String(key, def string) string {
+	if v, ok := s[key].(string); ok {
+		return v
+	}
+	return def
+}
+
+// Bool returns the boolean value of key, or def if unset or not a boolean
+func (s Section) Bool(key string, def bool) bool {
+	if v, ok := s[key].(bool); ok {
+		return v
+	}
+	return def
+}
+
+// Int returns the integer value of key, or def if unset or not an integer
+func (s Section) Int(key string, def int) int {
+	if v, ok := s[key].(int64); ok {
+		return int(v)
+	}
+	return def
+}
+
+// Duration returns the duration value of key. Both strings like "1m30s"
+// and plain numbers of seconds are accepted.
+func (s Section) Duration(key string, def time.Duration) time.Duration {
+	switch v := s[key].(type) {
+	case string:
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	case int64:
+		if v > 0 {
+			return time.Duration(v) * time.Second
+		}
+	case float64:
+		if v > 0 {
+			return time.Duration(v * float64(time.Second))
+		}
+	}
+	return def
+}
+
+// Strings returns the string array value of key, or def if unset
+func (s Section) Strings(key string, def []string) []string {
+	values, ok := s[key].([]any)
+	if !ok {
+		return def
+	}
+	var out []string
+	for _, v := range values {
+		if str, ok := v.(string); ok {
+			out = append(out, str)
+		}
+	}
+	return out
+}
+
+// Ints returns the integer array value of key, or def if unset
+func (s Section) Ints(key string, def []int) []int {
+	values, ok := s[key].([]any)
+	if !ok {
+		return def
+	}
+	var out []int
+	for _, v := range values {
+		if n, ok := v.(int64); ok {
+			out = append(out, int(n))
+		}
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"table defined twice", "[a]\nx = 1\n[a]\ny = 2\n"},
+		{"inline table extended by a header", "a = { x = 1 }\n[a]\ny = 2\n"},
+		{"duplicate key", "a = 1\na = 2\n"},
+		{"leading zero", "a = 040\n"},
+		{"bare word", "a = yes\n"},
+	}
+	for _, tt := range tests {
+		if _, err := parseTOML(tt.input); err == nil {
+			t.Errorf("%s: parseTOML(%q) succeeded, want an error", tt.name, tt.input)
+		}
+	}
+}
+
+func TestConfigSections(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     []string
+	}{
+		{"array of tables", "[[plugin]]\nname = \"a\"\n[[plugin]]\nname = \"b\"\n", []string{"a", "b"}},
+		{"array of inline tables", "plugin = [{ name = \"a\" }, { name = \"b\" }]\n", []string{"a", "b"}},
+		{"single table", "[plugin]\nname = \"a\"\n", nil},
+		{"unset", "", nil},
+	}
+	for _, tt := range tests {
+		values, err := parseTOML(tt.document)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var names []string
+		for _, section := range (&Config{values: values}).Sections("plugin") {
+			names = append(names, section.String("name", ""))
+		}
+		if !slices.Equal(names, tt.want) {
+			t.Errorf("%s: sections = %q, want %q", tt.name, names, tt.want)
+		}
+	}
+}
+
+func TestSectionGetters(t *testing.T) {
+	values, err := parseTOML(`
+[test]
+name = "x"
+flag = true
+count = 0x10
+seconds = 5
+fraction = 0.5
+duration = "1m30s"
+names = ["a", "b"]
+numbers = [1, 2, 3]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := (&Config{values: values}).Section("test")
+
+	if got := s.String("name", ""); got != "x" {
+		t.Errorf("String = %q, want x", got)
+	}
+	if got := s.Bool("flag", false); !got {
+		t.Error("Bool = false, want true")
+	}
+	if got := s.Int("count", 0); got != 16 {
+		t.Errorf("Int = %d, want 16", got)
+	}
+	if got := s.Int("name", 7); got != 7 {
+		t.Errorf("Int of a string = %d, want the default 7", got)
+	}
+	durations := map[string]time.Duration{
+		"seconds":  5 * time.Second,
+		"fraction": 500 * time.Millisecond,
+		"duration": 90 * time.Second,
+		"missing":  time.Hour,
+	}
+	for key, want := range durations {
+		if got := s.Duration(key, time.Hour); got != want {
+			t.Errorf("Duration(%s) = %s, want %s", key, got, want)
+		}
+	}
+	if got := s.Strings("names", nil); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Strings = %q, want [a b]", got)
+	}
+	if got := s.Ints("numbers", nil); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("Ints = %v, want [1 2 3]", got)
+	}
+}
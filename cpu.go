@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+	"github.com/shirou/gopsutil/v4/cpu"
+)
+
+// Delay between the two samples taken when no previous sample exists
+const cpuFirstSampleWindow = 250 * time.Millisecond
+
+// cpuTimes holds the cumulative jiffies of one "cpu" line of /proc/stat
+type cpuTimes struct {
+	user, nice, system, idle, iowait, irq, softirq, steal uint64
+}
+
+func (t cpuTimes) total() uint64 {
+	// guest and guest_nice are already included in user and nice
+	return t.user + t.nice + t.system + t.idle + t.iowait + t.irq + t.softirq + t.steal
+}
+
+// readCPUTimes parses the aggregate and per-core cpu lines of a /proc/stat
+// file. Per-core times are keyed by the N of "cpuN"; offline cores are
+// missing.
+func readCPUTimes(path string) (total cpuTimes, perCore map[int]cpuTimes, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return cpuTimes{}, nil, err
+	}
+	defer f.Close()
+
+	found := false
+	perCore = make(map[int]cpuTimes)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+
+		// Missing trailing columns (old kernels) stay zero
+		var values [8]uint64
+		for i := range values {
+			if i+1 >= len(fields) {
+				break
+			}
+			values[i], err = strconv.ParseUint(fields[i+1], 10, 64)
+			if err != nil {
+				return cpuTimes{}, nil, fmt.Errorf("parse %s: %w", path, err)
+			}
+		}
+		times := cpuTimes{
+			user: values[0], nice: values[1], system: values[2], idle: values[3],
+			iowait: values[4], irq: values[5], softirq: values[6], steal: values[7],
+		}
+
+		if fields[0] == "cpu" {
+			total = times
+			found = true
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(fields[0], "cpu"))
+		if err != nil {
+			return cpuTimes{}, nil, fmt.Errorf("parse %s: unexpected %q", path, fields[0])
+		}
+		perCore[id] = times
+	}
+	if err := scanner.Err(); err != nil {
+		return cpuTimes{}, nil, err
+	}
+	if !found {
+		return cpuTimes{}, nil, fmt.Errorf("no cpu line in %s", path)
+	}
+	return total, perCore, nil
+}
+
+// cpuBreakdown returns the percentage of time spent in each state between
+// two samples
+func cpuBreakdown(prev, cur cpuTimes) types.CPUTimes {
+	elapsed := float64(cur.total()) - float64(prev.total())
+	if elapsed <= 0 {
+		return types.CPUTimes{Idle: 100}
+	}
+	percent := func(a, b uint64) float64 {
+		// Counters can go backwards when a core goes offline
+		if b < a {
+			return 0
+		}
+		return float64(b-a) / elapsed * 100
+	}
+
+	times := types.CPUTimes{
+		User:   percent(prev.user+prev.nice, cur.user+cur.nice),
+		System: percent(prev.system, cur.system),
+		IOWait: percent(prev.iowait, cur.iowait),
+		IRQ:    percent(prev.irq+prev.softirq, cur.irq+cur.softirq),
+		Steal:  percent(prev.steal, cur.steal),
+		Idle:   percent(prev.idle, cur.idle),
+	}
+	times.Usage = max(0, 100-times.Idle-times.IOWait)
+	return times
+}
+
+// cpuSample is one read of /proc/stat
+type cpuSample struct {
+	total   cpuTimes
+	perCore map[int]cpuTimes
+}
+
+// cpuSampler computes CPU usage from the delta between consecutive reads of
+// /proc/stat, so every sample covers the full time since the previous one
+type cpuSampler = deltaSampler[cpuSample, *types.CPUInfo]
+
+// newCPUSampler returns a sampler of <proc_root>/stat as set in [cpu]
+func newCPUSampler() *cpuSampler {
+	return newDeltaSampler(cpuFirstSampleWindow, readCPUSample, cpuUsage)
+}
+
+// readCPUSample reads <proc_root>/stat
+func readCPUSample() (cpuSample, error) {
+	procRoot := currentConfig().Section("cpu").String("proc_root", "/proc")
+	total, perCore, err := readCPUTimes(filepath.Join(procRoot, "stat"))
+	return cpuSample{total: total, perCore: perCore}, err
+}
+
+// cpuUsage computes the usage of all cores and of each core between two
+// samples. Cores are ordered by number like cpu_cores; a core that was
+// offline in the previous sample reports zero.
+func cpuUsage(prev, cur cpuSample, _ time.Duration) *types.CPUInfo {
+	info := &types.CPUInfo{
+		Model:   cpuModel(),
+		Total:   cpuBreakdown(prev.total, cur.total),
+		PerCore: make([]types.CPUTimes, 0, len(cur.perCore)),
+	}
+	for _, id := range slices.Sorted(maps.Keys(cur.perCore)) {
+		var times types.CPUTimes
+		if prevTimes, ok := prev.perCore[id]; ok {
+			times = cpuBreakdown(prevTimes, cur.perCore[id])
+		}
+		info.PerCore = append(info.PerCore, times)
+	}
+	return info
+}
+
+// cpuModel returns the CPU model name, read once
+var cpuModel = sync.OnceValue(func() string {
+	infos, err := cpu.Info()
+	if err != nil || len(infos) == 0 {
+		return ""
+	}
+	return infos[0].ModelName
+})
+
+// cpuCollector reports a per-core and total breakdown of CPU time
+type cpuCollector struct {
+	sampler *cpuSampler
+}
+
+func init() {
+	RegisterCollector(&cpuCollector{sampler: newCPUSampler()})
+}
+
+// Name returns the data type name
+func (c *cpuCollector) Name() string {
+	return "cpu"
+}
+
+// Interval returns the configured polling interval
+func (c *cpuCollector) Interval() time.Duration {
+	return currentConfig().Section("cpu").Duration("interval", 3*time.Second)
+}
+
+// Snapshot returns the CPU usage of the last sample
+func (c *cpuCollector) Snapshot() (any, error) {
+	return c.info(c.sampler.latest)
+}
+
+// Sample returns the CPU usage since the previous sample
+func (c *cpuCollector) Sample() (any, error) {
+	return c.info(c.sampler.sample)
+}
+
+// info reads the CPU usage with one of the sampler methods
+func (c *cpuCollector) info(sample func(maxAge time.Duration) (*types.CPUInfo, error)) (any, error) {
+	info, err := sample(2 * c.Interval())
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Start emits the CPU usage every interval until ctx is cancelled
+func (c *cpuCollector) Start(ctx context.Context, emit EmitFunc) error {
+	return pollCollector(ctx, c, emit)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+// Two reads of /proc/stat 100 jiffies per core apart. cpu1 goes offline in
+// between, so cpu2 must still be compared with cpu2.
+const (
+	cpuStatBefore = `cpu  1000 100 500 8000 200 50 50 0 0 0
+cpu0 300 50 150 2500 50 10 10 0 0 0
+cpu1 400 0 200 2400 100 20 20 0 0 0
+cpu2 300 50 150 3100 50 20 20 0 0 0
+intr 123456 0 0
+ctxt 987654
+btime 1700000000
+processes 4242
+procs_running 2
+procs_blocked 0
+`
+	cpuStatAfter = `cpu  1100 100 540 8250 210 50 50 0 0 0
+cpu0 370 50 170 2510 50 10 10 0 0 0
+cpu2 300 50 150 3190 50 20 20 10 0 0
+intr 123999 0 0
+ctxt 988000
+`
+)
+
+func TestReadCPUTimes(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"stat": cpuStatBefore})
+
+	total, perCore, err := readCPUTimes(filepath.Join(root, "stat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (cpuTimes{user: 1000, nice: 100, system: 500, idle: 8000, iowait: 200, irq: 50, softirq: 50}); total != want {
+		t.Errorf("total = %+v, want %+v", total, want)
+	}
+	if len(perCore) != 3 {
+		t.Fatalf("got %d cores, want 3", len(perCore))
+	}
+	if want := (cpuTimes{user: 400, system: 200, idle: 2400, iowait: 100, irq: 20, softirq: 20}); perCore[1] != want {
+		t.Errorf("cpu1 = %+v, want %+v", perCore[1], want)
+	}
+
+	writeFiles(t, root, map[string]string{"stat": "intr 1\n"})
+	if _, _, err := readCPUTimes(filepath.Join(root, "stat")); err == nil {
+		t.Error("expected an error without a cpu line")
+	}
+	writeFiles(t, root, map[string]string{"stat": "cpu 1 2 3 x\n"})
+	if _, _, err := readCPUTimes(filepath.Join(root, "stat")); err == nil {
+		t.Error("expected an error for a malformed cpu line")
+	}
+}
+
+func TestCPUBreakdown(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"before": cpuStatBefore, "after": cpuStatAfter})
+
+	prevTotal, prevPerCore, err := readCPUTimes(filepath.Join(root, "before"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	total, perCore, err := readCPUTimes(filepath.Join(root, "after"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 400 jiffies elapsed in total
+	assertCPUTimes(t, "total", cpuBreakdown(prevTotal, total),
+		types.CPUTimes{Usage: 35, User: 25, System: 10, IOWait: 2.5, Idle: 62.5})
+	assertCPUTimes(t, "cpu0", cpuBreakdown(prevPerCore[0], perCore[0]),
+		types.CPUTimes{Usage: 90, User: 70, System: 20, Idle: 10})
+	assertCPUTimes(t, "cpu2", cpuBreakdown(prevPerCore[2], perCore[2]),
+		types.CPUTimes{Usage: 10, Steal: 10, Idle: 90})
+	assertCPUTimes(t, "no time elapsed", cpuBreakdown(total, total), types.CPUTimes{Idle: 100})
+
+	info := cpuUsage(cpuSample{prevTotal, prevPerCore}, cpuSample{total, perCore}, 0)
+	if len(info.PerCore) != 2 {
+		t.Fatalf("got %d cores, want 2", len(info.PerCore))
+	}
+	if info.PerCore[1].Steal != 10 {
+		t.Errorf("second core = %+v, want cpu2", info.PerCore[1])
+	}
+}
+
+func assertCPUTimes(t *testing.T, name string, got, want types.CPUTimes) {
+	t.Helper()
+	fields := []struct {
+		name      string
+		got, want float64
+	}{
+		{"usage", got.Usage, want.Usage},
+		{"user", got.User, want.User},
+		{"system", got.System, want.System},
+		{"iowait", got.IOWait, want.IOWait},
+		{"irq", got.IRQ, want.IRQ},
+		{"steal", got.Steal, want.Steal},
+		{"idle", got.Idle, want.Idle},
+	}
+	for _, f := range fields {
+		if math.Abs(f.got-f.want) > 1e-9 {
+			t.Errorf("%s %s = %v, want %v", name, f.name, f.got, f.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+// Core types reported in CPUCoreInfo
+const (
+	coreTypePerformance = "performance"
+	coreTypeEfficiency  = "efficiency"
+)
+
+// readCPUCores reports frequency, governor and topology of every online CPU
+// from <sysRoot>/devices/system/cpu
+func readCPUCores(sysRoot string) ([]types.CPUCoreInfo, error) {
+	cpuDir := filepath.Join(sysRoot, "devices", "system", "cpu")
+	online, err := readCPUList(filepath.Join(cpuDir, "online"))
+	if err != nil {
+		return nil, err
+	}
+	coreTypes := readCoreTypes(sysRoot, cpuDir, online)
+
+	cores := make([]types.CPUCoreInfo, 0, len(online))
+	for _, id := range online {
+		dir := filepath.Join(cpuDir, fmt.Sprintf("cpu%d", id))
+		core := types.CPUCoreInfo{
+			ID:                          id,
+			CurrentFreqMHz:              readSysfsInt(filepath.Join(dir, "cpufreq", "scaling_cur_freq")) / 1000,
+			MinFreqMHz:                  readSysfsInt(filepath.Join(dir, "cpufreq", "scaling_min_freq")) / 1000,
+			MaxFreqMHz:                  readSysfsInt(filepath.Join(dir, "cpufreq", "scaling_max_freq")) / 1000,
+			Governor:                    readSysfsString(filepath.Join(dir, "cpufreq", "scaling_governor")),
+			EnergyPerformancePreference: readSysfsString(filepath.Join(dir, "cpufreq", "energy_performance_preference")),
+			CoreType:                    coreTypes[id],
+			CoreID:                      readSysfsInt(filepath.Join(dir, "topology", "core_id")),
+		}
+		if core.SMTSiblings, err = readCPUList(filepath.Join(dir, "topology", "thread_siblings_list")); err != nil {
+			core.SMTSiblings = []int{id}
+		}
+		cores = append(cores, core)
+	}
+	return cores, nil
+}
+
+// readCoreTypes classifies CPUs as performance or efficiency cores. Intel
+// hybrid CPUs expose separate cpu_core and cpu_atom PMUs; on other hybrid
+// designs (e.g. ARM big.LITTLE) cores with less than the highest
+// cpu_capacity are efficiency cores. Homogeneous CPUs get no core type.
+func readCoreTypes(sysRoot, cpuDir string, online []int) map[int]string {
+	coreTypes := make(map[int]string)
+
+	pCores, errP := readCPUList(filepath.Join(sysRoot, "devices", "cpu_core", "cpus"))
+	eCores, errE := readCPUList(filepath.Join(sysRoot, "devices", "cpu_atom", "cpus"))
+	if errP == nil && errE == nil {
+		for _, id := range pCores {
+			coreTypes[id] = coreTypePerformance
+		}
+		for _, id := range eCores {
+			coreTypes[id] = coreTypeEfficiency
+		}
+		return coreTypes
+	}
+
+	capacities := make(map[int]int)
+	maxCapacity := 0
+	for _, id := range online {
+		capacity := readSysfsInt(filepath.Join(cpuDir, fmt.Sprintf("cpu%d", id), "cpu_capacity"))
+		capacities[id] = capacity
+		maxCapacity = max(maxCapacity, capacity)
+	}
+	hybrid := false
+	for _, capacity := range capacities {
+		if capacity > 0 && capacity < maxCapacity {
+			hybrid = true
+		}
+	}
+	if !hybrid {
+		return coreTypes
+	}
+	for id, capacity := range capacities {
+		if capacity == maxCapacity {
+			coreTypes[id] = coreTypePerformance
+		} else {
+			coreTypes[id] = coreTypeEfficiency
+		}
+	}
+	return coreTypes
+}
+
+// readCPUList parses a sysfs CPU list such as "0-3,8,10-11"
+func readCPUList(path string) ([]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseCPUList(strings.TrimSpace(string(data)))
+}
+
+func parseCPUList(list string) ([]int, error) {
+	var cpus []int
+	if list == "" {
+		return cpus, nil
+	}
+	for _, part := range strings.Split(list, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpu list %q", list)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(last); err != nil || end < start {
+				return nil, fmt.Errorf("invalid cpu list %q", list)
+			}
+		}
+		for id := start; id <= end; id++ {
+			cpus = append(cpus, id)
+		}
+	}
+	slices.Sort(cpus)
+	return cpus, nil
+}
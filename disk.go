@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+	"golang.org/x/sys/unix"
+)
+
+// Filesystem types that do not store user data
+var defaultDiskExcludeTypes = []string{
+	"autofs", "binfmt_misc", "bpf", "cgroup", "cgroup2", "configfs", "debugfs",
+	"devpts", "devtmpfs", "efivarfs", "fuse.gvfsd-fuse", "fuse.portal", "fusectl",
+	"hugetlbfs", "mqueue", "nsfs", "proc", "pstore", "ramfs", "rpc_pipefs",
+	"securityfs", "selinuxfs", "squashfs", "sysfs", "tmpfs", "tracefs",
+}
+
+// diskCollector reports the usage of mounted filesystems and publishes
+// immediately when a filesystem is mounted or unmounted
+type diskCollector struct{}
+
+func init() {
+	RegisterCollector(&diskCollector{})
+}
+
+// mountEntry is a line of /proc/self/mountinfo
+type mountEntry struct {
+	device     string // major:minor
+	mountPoint string
+	fsType     string
+	source     string
+	readOnly   bool
+}
+
+// Name returns the data type name
+func (d *diskCollector) Name() string {
+	return "disk"
+}
+
+// Interval returns the configured polling interval
+func (d *diskCollector) Interval() time.Duration {
+	return currentConfig().Section("disk").Duration("interval", 10*time.Second)
+}
+
+// mountinfoPath returns the mount table of the daemon's mount namespace
+func (d *diskCollector) mountinfoPath() string {
+	return filepath.Join(currentConfig().Section("disk").String("proc_root", "/proc"), "self", "mountinfo")
+}
+
+// Snapshot reads the mount table and the usage of every filesystem
+func (d *diskCollector) Snapshot() (any, error) {
+	f, err := os.Open(d.mountinfoPath())
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	mounts, err := parseMountinfo(f)
+	if err != nil {
+		return nil, err
+	}
+	return diskUsage(mounts)
+}
+
+// Start emits the usage every interval and whenever the mount table
+// changes, until ctx is cancelled
+func (d *diskCollector) Start(ctx context.Context, emit EmitFunc) error {
+	f, err := os.Open(d.mountinfoPath())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var previous []string
+	publish := func() error {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		// Reading the table also acknowledges the pending change
+		mounts, err := parseMountinfo(f)
+		if err != nil {
+			return err
+		}
+		info, err := diskUsage(mounts)
+		if info == nil {
+			return err
+		}
+		if err != nil {
+			reportCollectorError(d.Name(), err)
+		}
+
+		current := make([]string, len(info.Filesystems))
+		for i, fs := range info.Filesystems {
+			current[i] = fs.MountPoint
+		}
+		if previous != nil {
+			info.Added = diffStrings(current, previous)
+			info.Removed = diffStrings(previous, current)
+		}
+		previous = current
+		emit(d.Name(), wrapData(d, info))
+		return nil
+	}
+
+	if err := publish(); err != nil {
+		return err
+	}
+	wake, err := openCancelEventfd(ctx)
+	if err != nil {
+		return err
+	}
+	defer wake.Close()
+
+	next := time.Now().Add(d.Interval())
+	fds := []unix.PollFd{{Fd: int32(f.Fd()), Events: unix.POLLPRI}, wake.pollFd()}
+	for {
+		// The kernel flags the mount table with POLLERR|POLLPRI when a
+		// filesystem is mounted or unmounted in this namespace
+		timeout := max(time.Until(next), 0)
+		n, err := unix.Poll(fds, int(timeout.Milliseconds()))
+		if err != nil && !errors.Is(err, unix.EINTR) {
+			return fmt.Errorf("poll mount table: %w", err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		changed := n > 0 && fds[0].Revents&unix.POLLPRI != 0
+		if changed || !time.Now().Before(next) {
+			if err := publish(); err != nil {
+				return err
+			}
+			next = time.Now().Add(d.Interval())
+		}
+	}
+}
+
+// parseMountinfo parses a mountinfo file. Filesystems mounted over each
+// other keep the last, visible mount.
+func parseMountinfo(r io.Reader) ([]mountEntry, error) {
+	var mounts []mountEntry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+		fields := strings.Fields(scanner.Text())
+		separator := slices.Index(fields, "-")
+		if separator < 6 || len(fields) < separator+3 {
+			continue
+		}
+		entry := mountEntry{
+			device:     fields[2],
+			mountPoint: unescapeMountinfo(fields[4]),
+			fsType:     fields[separator+1],
+			source:     unescapeMountinfo(fields[separator+2]),
+			readOnly:   slices.Contains(strings.Split(fields[5], ","), "ro"),
+		}
+		mounts = slices.DeleteFunc(mounts, func(m mountEntry) bool {
+			return m.mountPoint == entry.mountPoint
+		})
+		mounts = append(mounts, entry)
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountinfo decodes the octal escapes (\040 for a space) used for
+// whitespace and backslashes in mountinfo
+func unescapeMountinfo(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// diskUsage returns the usage of the filesystems that are not excluded in
+// the [disk] section. A filesystem mounted several times, e.g. bind mounts
+// or btrfs subvolumes, is reported once under its shortest mount point.
+func diskUsage(mounts []mountEntry) (*types.DiskInfo, error) {
+	settings := currentConfig().Section("disk")
+	excludeTypes := settings.Strings("exclude_types", defaultDiskExcludeTypes)
+	excludePaths := settings.Strings("exclude_paths", nil)
+
+	byDevice := make(map[string]mountEntry)
+	for _, m := range mounts {
+		if slices.Contains(excludeTypes, m.fsType) || isExcludedPath(m.mountPoint, excludePaths) {
+			continue
+		}
+		if seen, ok := byDevice[m.device]; ok && len(seen.mountPoint) <= len(m.mountPoint) {
+			continue
+		}
+		byDevice[m.device] = m
+	}
+
+	info := &types.DiskInfo{Filesystems: []types.Filesystem{}}
+	var errs []error
+	for _, m := range byDevice {
+		var st unix.Statfs_t
+		if err := unix.Statfs(m.mountPoint, &st); err != nil {
+			errs = append(errs, fmt.Errorf("statfs %s: %w", m.mountPoint, err))
+			continue
+		}
+		size := uint64(st.Bsize)
+		fs := types.Filesystem{
+			MountPoint: m.mountPoint,
+			Device:     m.source,
+			Type:       m.fsType,
+			ReadOnly:   m.readOnly,
+			Total:      st.Blocks * size,
+			Used:       (st.Blocks - st.Bfree) * size,
+			Free:       st.Bavail * size,
+			Inodes:     st.Files,
+			InodesUsed: st.Files - st.Ffree,
+			InodesFree: st.Ffree,
+		}
+		if fs.Used+fs.Free > 0 {
+			fs.UsedPercent = float64(fs.Used) / float64(fs.Used+fs.Free) * 100
+		}
+		if fs.Inodes > 0 {
+			fs.InodesUsedPercent = float64(fs.InodesUsed) / float64(fs.Inodes) * 100
+		}
+		info.Filesystems = append(info.Filesystems, fs)
+	}
+	slices.SortFunc(info.Filesystems, func(a, b types.Filesystem) int {
+		return strings.Compare(a.MountPoint, b.MountPoint)
+	})
+	return info, errors.Join(errs...)
+}
+
+// isExcludedPath reports whether mountPoint is one of paths or below one
+func isExcludedPath(mountPoint string, paths []string) bool {
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		if mountPoint == p || strings.HasPrefix(mountPoint, strings.TrimSuffix(p, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// diffStrings returns the elements of a that are not in b
+func diffStrings(a, b []string) []string {
+	var diff []string
+	for _, s := range a {
+		if !slices.Contains(b, s) {
+			diff = append(diff, s)
+		}
+	}
+	return diff
+}
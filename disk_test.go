@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseMountinfo(t *testing.T) {
+	mountinfo := `22 1 8:2 / / rw,relatime shared:1 - ext4 /dev/sda2 rw
+23 22 0:21 / /proc rw,nosuid,nodev,noexec,relatime shared:5 - proc proc rw
+40 22 8:1 / /boot/efi ro,relatime shared:30 - vfat /dev/sda1 ro,fmask=0022
+41 22 8:3 / /mnt/My\040Disk rw,relatime - ext4 /dev/sda3 rw
+42 22 0:45 / /mnt/My\040Disk rw,relatime - ntfs3 /dev/sdb1 rw
+malformed line
+43 22 0:46 / /srv rw - nfs4 server:/export\134share rw
+`
+	mounts, err := parseMountinfo(strings.NewReader(mountinfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []mountEntry{
+		{device: "8:2", mountPoint: "/", fsType: "ext4", source: "/dev/sda2"},
+		{device: "0:21", mountPoint: "/proc", fsType: "proc", source: "proc"},
+		{device: "8:1", mountPoint: "/boot/efi", fsType: "vfat", source: "/dev/sda1", readOnly: true},
+		// Mounted over /dev/sda3, only the visible mount is kept
+		{device: "0:45", mountPoint: "/mnt/My Disk", fsType: "ntfs3", source: "/dev/sdb1"},
+		{device: "0:46", mountPoint: "/srv", fsType: "nfs4", source: `server:/export\share`},
+	}
+	if !slices.Equal(mounts, want) {
+		t.Errorf("mounts = %+v\nwant %+v", mounts, want)
+	}
+}
+
+func TestUnescapeMountinfo(t *testing.T) {
+	tests := map[string]string{
+		"/plain":          "/plain",
+		`/a\040b`:         "/a b",
+		`/tab\011x\012y`:  "/tab\tx\ny",
+		`/back\134slash`:  `/back\slash`,
+		`/short\04`:       `/short\04`,
+		`/not\999octal`:   `/not\999octal`,
+		`/end\040`:        "/end ",
+		`\040\040leading`: "  leading",
+	}
+	for in, want := range tests {
+		if got := unescapeMountinfo(in); got != want {
+			t.Errorf("unescapeMountinfo(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDiskUsageDedup(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"data", "data/sub", "bind", "excluded", "proc"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	useConfig(t, "[disk]\nexclude_paths = [\""+filepath.ToSlash(filepath.Join(root, "excluded"))+"\"]\n")
+
+	mounts := []mountEntry{
+		{device: "8:2", mountPoint: filepath.Join(root, "data", "sub"), fsType: "btrfs", source: "/dev/sda2"},
+		{device: "8:2", mountPoint: filepath.Join(root, "data"), fsType: "btrfs", source: "/dev/sda2"},
+		{device: "8:2", mountPoint: filepath.Join(root, "bind", "x"), fsType: "btrfs", source: "/dev/sda2"},
+		{device: "8:3", mountPoint: filepath.Join(root, "excluded"), fsType: "ext4", source: "/dev/sda3"},
+		{device: "0:21", mountPoint: filepath.Join(root, "proc"), fsType: "proc", source: "proc"},
+		{device: "8:4", mountPoint: filepath.Join(root, "bind"), fsType: "ext4", source: "/dev/sda4", readOnly: true},
+	}
+	info, err := diskUsage(mounts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, fs := range info.Filesystems {
+		got = append(got, fs.MountPoint+" "+fs.Device)
+		if fs.Total == 0 || fs.UsedPercent < 0 || fs.UsedPercent > 100 {
+			t.Errorf("%s: total %d, used %.1f%%", fs.MountPoint, fs.Total, fs.UsedPercent)
+		}
+	}
+	want := []string{
+		filepath.Join(root, "bind") + " /dev/sda4",
+		filepath.Join(root, "data") + " /dev/sda2",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("filesystems = %q, want %q", got, want)
+	}
+	if !info.Filesystems[0].ReadOnly {
+		t.Error("read-only flag was lost")
+	}
+}
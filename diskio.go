@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+// Delay between the two samples taken when no previous sample exists
+const diskIOFirstSampleWindow = 250 * time.Millisecond
+
+// /proc/diskstats counts in 512 byte sectors regardless of the device
+const diskSectorSize = 512
+
+// Devices without useful I/O statistics
+var defaultDiskIOExclude = []string{"loop*", "ram*", "zram*"}
+
+// diskStats holds the cumulative counters of a /proc/diskstats line
+type diskStats struct {
+	reads, readSectors   uint64
+	writes, writeSectors uint64
+	ioTicks              uint64 // milliseconds spent doing I/O
+}
+
+// readDiskStats parses a /proc/diskstats file, keyed by device name
+func readDiskStats(path string) (map[string]diskStats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stats := make(map[string]diskStats)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// major minor name reads merged sectors ms writes merged sectors ms in_flight io_ticks ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 13 {
+			continue
+		}
+		var values [10]uint64
+		for i := range values {
+			values[i], err = strconv.ParseUint(fields[i+3], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", path, err)
+			}
+		}
+		stats[fields[2]] = diskStats{
+			reads: values[0], readSectors: values[2],
+			writes: values[4], writeSectors: values[6],
+			ioTicks: values[9],
+		}
+	}
+	return stats, scanner.Err()
+}
+
+// diskIORates computes the rates of every device between two reads of
+// /proc/diskstats
+func diskIORates(prev, cur map[string]diskStats, elapsed time.Duration) map[string]types.BlockDeviceIO {
+	seconds := elapsed.Seconds()
+	rates := make(map[string]types.BlockDeviceIO, len(cur))
+	for name, stats := range cur {
+		prevStats, ok := prev[name]
+		if !ok || seconds <= 0 {
+			rates[name] = types.BlockDeviceIO{Name: name}
+			continue
+		}
+		// Counters restart when a device is removed and added again
+		rate := func(a, b uint64) float64 {
+			if b < a {
+				return 0
+			}
+			return float64(b-a) / seconds
+		}
+		rates[name] = types.BlockDeviceIO{
+			Name:             name,
+			ReadBytesPerSec:  rate(prevStats.readSectors, stats.readSectors) * diskSectorSize,
+			WriteBytesPerSec: rate(prevStats.writeSectors, stats.writeSectors) * diskSectorSize,
+			ReadIOPS:         rate(prevStats.reads, stats.reads),
+			WriteIOPS:        rate(prevStats.writes, stats.writes),
+			Utilization:      min(rate(prevStats.ioTicks, stats.ioTicks)/10, 100), // ms per s to percent
+		}
+	}
+	return rates
+}
+
+// diskIOCollector reports read and write throughput, IOPS and utilisation
+// of block devices, with partitions grouped under their disk
+type diskIOCollector struct {
+	sampler *deltaSampler[map[string]diskStats, map[string]types.BlockDeviceIO]
+}
+
+func init() {
+	RegisterCollector(&diskIOCollector{
+		sampler: newDeltaSampler(diskIOFirstSampleWindow, readDiskIOSample, diskIORates),
+	})
+}
+
+// readDiskIOSample reads <proc_root>/diskstats as set in [diskio]
+func readDiskIOSample() (map[string]diskStats, error) {
+	return readDiskStats(filepath.Join(currentConfig().Section("diskio").String("proc_root", "/proc"), "diskstats"))
+}
+
+// Name returns the data type name
+func (d *diskIOCollector) Name() string {
+	return "diskio"
+}
+
+// Interval returns the configured polling interval
+func (d *diskIOCollector) Interval() time.Duration {
+	return currentConfig().Section("diskio").Duration("interval", 3*time.Second)
+}
+
+// Snapshot returns the I/O rates of the last sample
+func (d *diskIOCollector) Snapshot() (any, error) {
+	return d.info(d.sampler.latest)
+}
+
+// Sample returns the I/O rates since the previous sample
+func (d *diskIOCollector) Sample() (any, error) {
+	return d.info(d.sampler.sample)
+}
+
+// info reads the I/O rates with one of the sampler methods and groups them
+// by disk
+func (d *diskIOCollector) info(sample func(maxAge time.Duration) (map[string]types.BlockDeviceIO, error)) (any, error) {
+	rates, err := sample(2 * d.Interval())
+	if err != nil {
+		return nil, err
+	}
+	settings := currentConfig().Section("diskio")
+	exclude := settings.Strings("exclude", defaultDiskIOExclude)
+	sysRoot := settings.String("sys_root", "/sys")
+
+	disks := make(map[string]*types.BlockDeviceIO)
+	partitions := make(map[string][]types.BlockDeviceIO)
+	for name, rate := range rates {
+		if matchesAny(name, exclude) {
+			continue
+		}
+		if parent := partitionParent(sysRoot, name); parent != "" {
+			partitions[parent] = append(partitions[parent], rate)
+			continue
+		}
+		disks[name] = &rate
+	}
+
+	info := &types.DiskIOInfo{Devices: []types.BlockDeviceIO{}}
+	for name, disk := range disks {
+		disk.Partitions = partitions[name]
+		slices.SortFunc(disk.Partitions, func(a, b types.BlockDeviceIO) int {
+			return naturalCompare(a.Name, b.Name)
+		})
+		info.Devices = append(info.Devices, *disk)
+	}
+	slices.SortFunc(info.Devices, func(a, b types.BlockDeviceIO) int {
+		return naturalCompare(a.Name, b.Name)
+	})
+	return info, nil
+}
+
+// Start emits the I/O rates every interval until ctx is cancelled
+func (d *diskIOCollector) Start(ctx context.Context, emit EmitFunc) error {
+	return pollCollector(ctx, d, emit)
+}
+
+// partitionParent returns the disk a partition belongs to, or "" if name is
+// not a partition
+func partitionParent(sysRoot, name string) string {
+	dir := filepath.Join(sysRoot, "class", "block", name)
+	if _, err := os.Stat(filepath.Join(dir, "partition")); err != nil {
+		return ""
+	}
+	// /sys/class/block/nvme0n1p1 -> ../../devices/.../nvme0n1/nvme0n1p1
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(filepath.Dir(resolved))
+}
+
+// matchesAny reports whether name matches one of the glob patterns
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
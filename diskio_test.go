@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+func TestReadDiskStats(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"diskstats": `   7       0 loop0 50 0 400 10 0 0 0 0 0 20 10 0 0 0 0
+ 259       0 nvme0n1 1000 10 20000 300 500 20 8000 400 0 600 700 0 0 0 0 0 0
+ 259       1 nvme0n1p1 900 10 18000 250 400 20 6000 300 0 500 550
+   8       0 short 1 2 3
+`,
+	})
+
+	stats, err := readDiskStats(filepath.Join(root, "diskstats"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]diskStats{
+		"loop0":     {reads: 50, readSectors: 400, ioTicks: 20},
+		"nvme0n1":   {reads: 1000, readSectors: 20000, writes: 500, writeSectors: 8000, ioTicks: 600},
+		"nvme0n1p1": {reads: 900, readSectors: 18000, writes: 400, writeSectors: 6000, ioTicks: 500},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+	for name, w := range want {
+		if stats[name] != w {
+			t.Errorf("%s = %+v, want %+v", name, stats[name], w)
+		}
+	}
+
+	writeFiles(t, root, map[string]string{"bad": "8 0 sda 1 x 3 4 5 6 7 8 9 10 11\n"})
+	if _, err := readDiskStats(filepath.Join(root, "bad")); err == nil {
+		t.Error("expected an error for a non-numeric counter")
+	}
+}
+
+func TestDiskIORates(t *testing.T) {
+	prev := map[string]diskStats{
+		"sda":  {reads: 100, readSectors: 1000, writes: 50, writeSectors: 2000, ioTicks: 100},
+		"sdb":  {reads: 500, readSectors: 5000},
+		"zram": {reads: 10},
+	}
+	cur := map[string]diskStats{
+		"sda":  {reads: 300, readSectors: 5000, writes: 70, writeSectors: 2400, ioTicks: 2600},
+		"sdb":  {reads: 10, readSectors: 100}, // removed and added again
+		"sdc":  {reads: 7},                    // new device
+		"zram": {reads: 10},
+	}
+	rates := diskIORates(prev, cur, 2*time.Second)
+
+	want := map[string]types.BlockDeviceIO{
+		"sda": {
+			Name: "sda", ReadBytesPerSec: 2000 * diskSectorSize, WriteBytesPerSec: 200 * diskSectorSize,
+			ReadIOPS: 100, WriteIOPS: 10, Utilization: 100, // 1250 ms per s is capped
+		},
+		"sdb":  {Name: "sdb"},
+		"sdc":  {Name: "sdc"},
+		"zram": {Name: "zram"},
+	}
+	if len(rates) != len(want) {
+		t.Fatalf("rates = %+v, want %+v", rates, want)
+	}
+	for name, w := range want {
+		got := rates[name]
+		if got.Name != w.Name || got.ReadBytesPerSec != w.ReadBytesPerSec || got.WriteBytesPerSec != w.WriteBytesPerSec ||
+			got.ReadIOPS != w.ReadIOPS || got.WriteIOPS != w.WriteIOPS || got.Utilization != w.Utilization {
+			t.Errorf("%s = %+v, want %+v", name, got, w)
+		}
+	}
+}
+
+func TestDiskIOGroupsPartitions(t *testing.T) {
+	root := t.TempDir()
+	sysRoot := filepath.Join(root, "sys")
+	writeFiles(t, sysRoot, map[string]string{
+		"devices/pci0000:00/nvme/nvme0n1/nvme0n1p1/partition": "1\n",
+		"devices/pci0000:00/nvme/nvme0n1/nvme0n1p2/partition": "2\n",
+		"devices/pci0000:00/nvme/nvme0n1/size":                "1000\n",
+		"devices/pci0000:00/ata/sda/sda1/partition":           "1\n",
+		"devices/virtual/block/loop0/size":                    "0\n",
+	})
+	links := map[string]string{
+		"nvme0n1":   "devices/pci0000:00/nvme/nvme0n1",
+		"nvme0n1p1": "devices/pci0000:00/nvme/nvme0n1/nvme0n1p1",
+		"nvme0n1p2": "devices/pci0000:00/nvme/nvme0n1/nvme0n1p2",
+		"sda":       "devices/pci0000:00/ata/sda",
+		"sda1":      "devices/pci0000:00/ata/sda/sda1",
+		"loop0":     "devices/virtual/block/loop0",
+	}
+	if err := os.MkdirAll(filepath.Join(sysRoot, "class", "block"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, target := range links {
+		if err := os.Symlink(filepath.Join("..", "..", target), filepath.Join(sysRoot, "class", "block", name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	line := func(name string) string {
+		return "259 0 " + name + " 1 0 8 1 1 0 8 1 0 1 2\n"
+	}
+	writeFiles(t, root, map[string]string{
+		"proc/diskstats": line("nvme0n1") + line("nvme0n1p2") + line("nvme0n1p1") + line("sda") + line("sda1") + line("loop0"),
+	})
+	useConfig(t, "[diskio]\nproc_root = \""+filepath.ToSlash(filepath.Join(root, "proc"))+
+		"\"\nsys_root = \""+filepath.ToSlash(sysRoot)+"\"\n")
+
+	d := &diskIOCollector{sampler: newDeltaSampler(time.Millisecond, readDiskIOSample, diskIORates)}
+	data, err := d.Sample()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, device := range data.(*types.DiskIOInfo).Devices {
+		var partitions []string
+		for _, p := range device.Partitions {
+			partitions = append(partitions, p.Name)
+		}
+		got = append(got, device.Name+"=["+strings.Join(partitions, " ")+"]")
+	}
+	// loop devices are excluded by default
+	want := []string{"nvme0n1=[nvme0n1p1 nvme0n1p2]", "sda=[sda1]"}
+	if !slices.Equal(got, want) {
+		t.Errorf("devices = %q, want %q", got, want)
+	}
+}
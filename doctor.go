@@ -0,0 +1,201 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/mdlayher/wifi"
+	"golang.org/x/sys/unix"
+)
+
+// Results of a doctor check
+const (
+	doctorOK   = "OK"
+	doctorWarn = "WARN"
+	doctorFail = "FAIL"
+)
+
+// doctorCheck diagnoses a single data source the daemon depends on
+type doctorCheck struct {
+	name string
+	run  func() (result string, detail string)
+}
+
+// runDoctor checks every data source, prints a report to stdout and returns
+// the exit code: 1 if any check failed, 0 otherwise
+func runDoctor() int {
+	checks := []doctorCheck{
+		{"config", checkConfig},
+		{"hyprland", checkHyprland},
+		{"mango", checkMango},
+		{"workspace", checkCompositor},
+		{"system bus", checkSystemBus},
+		{"bluez", checkBluez},
+		{"nl80211", checkWifi},
+		{"battery", checkBattery},
+		{"socket", checkSocketPath},
+	}
+
+	exitCode := 0
+	for _, check := range checks {
+		result, detail := check.run()
+		if result == doctorFail {
+			exitCode = 1
+		}
+		fmt.Printf("[%-4s] %-10s %s\n", result, check.name, detail)
+	}
+	return exitCode
+}
+
+func checkConfig() (string, string) {
+	path := configPath()
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return doctorOK, fmt.Sprintf("%s not found, using defaults", path)
+	}
+	if err := loadConfig(); err != nil {
+		return doctorFail, err.Error()
+	}
+	return doctorOK, path
+}
+
+func checkHyprland() (string, string) {
+	if os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") == "" {
+		return doctorWarn, "HYPRLAND_INSTANCE_SIGNATURE is not set"
+	}
+	if os.Getenv("XDG_RUNTIME_DIR") == "" {
+		return doctorFail, "XDG_RUNTIME_DIR is not set, cannot locate the Hyprland sockets"
+	}
+
+	provider := NewHyprlandProvider()
+	for _, sock := range []string{".socket.sock", ".socket2.sock"} {
+		conn, err := provider.openSocket(sock)
+		if err != nil {
+			return doctorFail, err.Error()
+		}
+		conn.Close()
+	}
+	return doctorOK, "command and event sockets are reachable"
+}
+
+func checkMango() (string, string) {
+	path, err := exec.LookPath("mmsg")
+	if err != nil {
+		return doctorWarn, "mmsg not found in PATH"
+	}
+	if out, err := exec.Command("mmsg", "-g", "-t").CombinedOutput(); err != nil {
+		// Mango is detected through mmsg, so this only means it is not the
+		// running compositor. The workspace check fails if none is found.
+		return doctorWarn, fmt.Sprintf("%s -g -t failed, Mango is not running: %v %s", path, err, strings.TrimSpace(string(out)))
+	}
+	return doctorOK, path + " responds"
+}
+
+func checkCompositor() (string, string) {
+	compositor := DetectCompositor()
+	order := currentConfig().Section("workspace").Strings("compositors", []string{"hyprland", "mango"})
+	if compositor == CompositorUnknown {
+		return doctorFail, fmt.Sprintf("no supported compositor detected (tried %s), WORKSPACE will be silent",
+			strings.Join(order, ", "))
+	}
+	return doctorOK, "detected " + compositor.String()
+}
+
+func checkSystemBus() (string, string) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return doctorFail, err.Error()
+	}
+	conn.Close()
+	return doctorOK, "connected"
+}
+
+func checkBluez() (string, string) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return doctorFail, "system bus unavailable"
+	}
+	defer conn.Close()
+
+	var hasOwner bool
+	err = conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, "org.bluez").Store(&hasOwner)
+	if err != nil {
+		return doctorFail, err.Error()
+	}
+	if !hasOwner {
+		return doctorFail, "org.bluez has no owner, is bluetoothd running?"
+	}
+	return doctorOK, "org.bluez is owned"
+}
+
+func checkWifi() (string, string) {
+	c, err := wifi.New()
+	if err != nil {
+		return doctorFail, fmt.Sprintf("cannot open nl80211: %v", err)
+	}
+	defer c.Close()
+
+	ifaces, err := c.Interfaces()
+	if err != nil {
+		return doctorFail, fmt.Sprintf("cannot list wifi interfaces: %v", err)
+	}
+	var names []string
+	for _, ifi := range ifaces {
+		if ifi.Name != "" {
+			names = append(names, ifi.Name)
+		}
+	}
+	if len(names) == 0 {
+		return doctorWarn, "no wifi interfaces, SSID and signal strength will be empty"
+	}
+	return doctorOK, "wifi interfaces: " + strings.Join(names, ", ")
+}
+
+func checkBattery() (string, string) {
+	power, err := readPowerSupplies(batterySysRoot())
+	if err != nil {
+		return doctorFail, err.Error()
+	}
+	var names []string
+	for _, battery := range power.Batteries {
+		names = append(names, battery.Name)
+	}
+	for _, adapter := range power.Adapters {
+		names = append(names, adapter.Name)
+	}
+	if len(power.Batteries) == 0 {
+		return doctorWarn, fmt.Sprintf("no battery found in %s, battery info will be empty",
+			filepath.Join(batterySysRoot(), "class", "power_supply"))
+	}
+	return doctorOK, "power supplies: " + strings.Join(names, ", ")
+}
+
+func checkSocketPath() (string, string) {
+	path := currentConfig().SocketPath()
+
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return doctorFail, path + " exists and is not a socket"
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return doctorOK, path + " is served by a running daemon"
+		}
+		if err := unix.Access(filepath.Dir(path), unix.W_OK|unix.X_OK); err != nil {
+			return doctorFail, fmt.Sprintf("stale socket %s cannot be replaced: %v", path, err)
+		}
+		return doctorOK, path + " is stale and will be replaced"
+	}
+
+	dir := filepath.Dir(path)
+	if err := unix.Access(dir, unix.W_OK|unix.X_OK); err != nil {
+		return doctorFail, fmt.Sprintf("cannot create sockets in %s: %v", dir, err)
+	}
+	return doctorOK, path + " can be created"
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cancelEventfd is an eventfd that becomes readable when a context is
+// cancelled. Added to a poll set, it wakes the poll on cancellation, so the
+// poll needs no timeout to notice it.
+type cancelEventfd struct {
+	*os.File
+	stop func() bool
+}
+
+// openCancelEventfd returns an eventfd signalled when ctx is cancelled
+func openCancelEventfd(ctx context.Context) (*cancelEventfd, error) {
+	fd, err := unix.Eventfd(0, unix.EFD_CLOEXEC|unix.EFD_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("create eventfd: %w", err)
+	}
+	f := os.NewFile(uintptr(fd), "eventfd")
+	stop := context.AfterFunc(ctx, func() {
+		f.Write(binary.NativeEndian.AppendUint64(nil, 1))
+	})
+	return &cancelEventfd{File: f, stop: stop}, nil
+}
+
+// pollFd returns the poll entry that reports the cancellation
+func (e *cancelEventfd) pollFd() unix.PollFd {
+	return unix.PollFd{Fd: int32(e.Fd()), Events: unix.POLLIN}
+}
+
+// Close releases the eventfd
+func (e *cancelEventfd) Close() error {
+	e.stop()
+	return e.File.Close()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestCancelEventfdWakesPoll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	wake, err := openCancelEventfd(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wake.Close()
+
+	polled := make(chan error, 1)
+	go func() {
+		fds := []unix.PollFd{wake.pollFd()}
+		_, err := unix.Poll(fds, -1)
+		polled <- err
+	}()
+
+	select {
+	case <-polled:
+		t.Fatal("poll returned before cancel")
+	case <-time.After(50 * time.Millisecond):
+	}
+	cancel()
+	select {
+	case err := <-polled:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("poll did not return after cancel")
+	}
+}
+
+func TestCancelEventfdClosedBeforeCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	wake, err := openCancelEventfd(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wake.Close(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+// Values of pwm*_enable
+const (
+	pwmEnableFull   = 0
+	pwmEnableManual = 1
+	pwmEnableAuto   = 2
+)
+
+// fanCollector reports fan speeds and PWM duty cycles from hwmon
+type fanCollector struct{}
+
+func init() {
+	RegisterCollector(&fanCollector{})
+}
+
+// Name returns the data type name
+func (f *fanCollector) Name() string {
+	return "fans"
+}
+
+// Interval returns the configured polling interval
+func (f *fanCollector) Interval() time.Duration {
+	return currentConfig().Section("fans").Duration("interval", 3*time.Second)
+}
+
+// Snapshot reads all fans and PWM channels
+func (f *fanCollector) Snapshot() (any, error) {
+	settings := currentConfig().Section("fans")
+	devices, err := listHwmon(settings.String("sys_root", "/sys"))
+	if err != nil {
+		return nil, err
+	}
+	allowControl := settings.Bool("allow_control", false)
+
+	info := &types.FanInfo{Fans: []types.Fan{}, PWM: []types.PWM{}}
+	for _, dev := range devices {
+		for _, channel := range dev.channels("fan", "input") {
+			rpm, ok := dev.attrInt(channel + "_input")
+			if !ok {
+				continue
+			}
+			info.Fans = append(info.Fans, types.Fan{
+				ID:    dev.id + "/" + channel,
+				Chip:  dev.name,
+				Label: fanLabel(dev, channel),
+				RPM:   rpm,
+			})
+		}
+
+		for _, channel := range dev.channels("pwm", "enable") {
+			duty, ok := dev.attrInt(channel)
+			if !ok {
+				continue
+			}
+			pwm := types.PWM{
+				ID:        dev.id + "/" + channel,
+				Chip:      dev.name,
+				Label:     fanLabel(dev, "fan"+strings.TrimPrefix(channel, "pwm")),
+				DutyCycle: float64(duty) / 255 * 100,
+			}
+			if enable, ok := dev.attrInt(channel + "_enable"); ok {
+				pwm.Mode = pwmMode(enable)
+			}
+			pwm.Controllable = allowControl && isWritable(dev.attrPath(channel)) &&
+				isWritable(dev.attrPath(channel+"_enable"))
+			info.PWM = append(info.PWM, pwm)
+		}
+	}
+	return info, nil
+}
+
+// Start emits the fan state every interval until ctx is cancelled
+func (f *fanCollector) Start(ctx context.Context, emit EmitFunc) error {
+	return pollCollector(ctx, f, emit)
+}
+
+// Set changes a PWM channel. It is only allowed when allow_control is set
+// in the [fans] section. Arguments:
+//
+//	<pwm id> auto
+//	<pwm id> full
+//	<pwm id> manual <duty cycle percent>
+func (f *fanCollector) Set(args []string) error {
+	settings := currentConfig().Section("fans")
+	if !settings.Bool("allow_control", false) {
+		return errors.New("fan control is disabled, set allow_control in [fans]")
+	}
+	if len(args) < 2 {
+		return errors.New("usage: SET FANS <pwm id> auto|full|manual <percent>")
+	}
+
+	dev, channel, err := findPWM(settings.String("sys_root", "/sys"), args[0])
+	if err != nil {
+		return err
+	}
+
+	switch args[1] {
+	case "auto":
+		return writeSysfsInt(dev.attrPath(channel+"_enable"), pwmEnableAuto)
+	case "full":
+		return writeSysfsInt(dev.attrPath(channel+"_enable"), pwmEnableFull)
+	case "manual":
+		if len(args) != 3 {
+			return errors.New("manual mode needs a duty cycle in percent")
+		}
+		percent, err := strconv.ParseFloat(args[2], 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return fmt.Errorf("invalid duty cycle %q, expected 0-100", args[2])
+		}
+		if err := writeSysfsInt(dev.attrPath(channel+"_enable"), pwmEnableManual); err != nil {
+			return err
+		}
+		return writeSysfsInt(dev.attrPath(channel), int(math.Round(percent/100*255)))
+	default:
+		return fmt.Errorf("unknown pwm mode %q", args[1])
+	}
+}
+
+// findPWM resolves a PWM id such as "hwmon5/pwm1"
+func findPWM(sysRoot, id string) (hwmonDevice, string, error) {
+	devID, channel, ok := strings.Cut(id, "/")
+	if ok && strings.HasPrefix(channel, "pwm") {
+		devices, err := listHwmon(sysRoot)
+		if err != nil {
+			return hwmonDevice{}, "", err
+		}
+		for _, dev := range devices {
+			if dev.id == devID {
+				if _, ok := dev.attrInt(channel + "_enable"); ok {
+					return dev, channel, nil
+				}
+			}
+		}
+	}
+	return hwmonDevice{}, "", fmt.Errorf("unknown pwm channel %q", id)
+}
+
+// fanLabel returns the label of a fan channel, or the channel name
+func fanLabel(dev hwmonDevice, channel string) string {
+	if label := dev.attr(channel + "_label"); label != "" {
+		return label
+	}
+	return channel
+}
+
+// pwmMode names a pwm*_enable value
+func pwmMode(enable int) string {
+	switch enable {
+	case pwmEnableFull:
+		return "full"
+	case pwmEnableManual:
+		return "manual"
+	default:
+		return "auto"
+	}
+}
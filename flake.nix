@@ -18,7 +18,7 @@
           # Path to your main.go or module root
           src = ./.;
 
-          vendorHash = "sha256-mqui9KN6LUUghjP/sgp09QKMIxWBrtwlM6gNkA+r7lc=";
+          vendorHash = "sha256-EVGJTW/faxUII7j0gTHMmeIzM66lgW3EIQXlzbw52fA=";
         };
 
         # Optional: create a dev environment with Go tools
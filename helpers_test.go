@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates the files below root, creating parent directories.
+// Keys are slash separated paths relative to root.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// useConfig makes the TOML document the active configuration for the
+// duration of the test
+func useConfig(t *testing.T, document string) {
+	t.Helper()
+	values, err := parseTOML(document)
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+
+	config.Lock()
+	old := config.c
+	config.c = &Config{values: values}
+	config.Unlock()
+
+	t.Cleanup(func() {
+		config.Lock()
+		config.c = old
+		config.Unlock()
+	})
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// equalOptional reports whether both values are nil or equal
+func equalOptional[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// deref formats an optional value for error messages
+func deref[T any](v *T) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
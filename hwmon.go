@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// hwmonDevice is a directory below /sys/class/hwmon
+type hwmonDevice struct {
+	id   string // directory name, e.g. "hwmon2"
+	dir  string
+	name string // driver name, e.g. "coretemp" or "thinkpad"
+}
+
+// listHwmon returns the hwmon devices below <sysRoot>/class/hwmon
+func listHwmon(sysRoot string) ([]hwmonDevice, error) {
+	base := filepath.Join(sysRoot, "class", "hwmon")
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []hwmonDevice
+	for _, entry := range entries {
+		dir := filepath.Join(base, entry.Name())
+		devices = append(devices, hwmonDevice{
+			id:   entry.Name(),
+			dir:  dir,
+			name: readSysfsString(filepath.Join(dir, "name")),
+		})
+	}
+	slices.SortFunc(devices, func(a, b hwmonDevice) int {
+		return naturalCompare(a.id, b.id)
+	})
+	return devices, nil
+}
+
+// attrPath returns the path of an attribute. Older drivers keep their
+// attributes in the device subdirectory instead of the hwmon directory.
+func (h hwmonDevice) attrPath(attr string) string {
+	path := filepath.Join(h.dir, attr)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	return filepath.Join(h.dir, "device", attr)
+}
+
+// attr returns the trimmed content of an attribute, or ""
+func (h hwmonDevice) attr(attr string) string {
+	return readSysfsString(h.attrPath(attr))
+}
+
+// attrInt returns an integer attribute and whether it exists
+func (h hwmonDevice) attrInt(attr string) (int, bool) {
+	value, err := strconv.Atoi(h.attr(attr))
+	return value, err == nil
+}
+
+// channels returns the sorted channel names (e.g. "temp1", "fan2") of the
+// attributes matching <prefix><N>_<suffix>
+func (h hwmonDevice) channels(prefix, suffix string) []string {
+	pattern := regexp.MustCompile("^(" + regexp.QuoteMeta(prefix) + `\d+)_` + regexp.QuoteMeta(suffix) + "$")
+	seen := make(map[string]bool)
+	for _, dir := range []string{h.dir, filepath.Join(h.dir, "device")} {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if m := pattern.FindStringSubmatch(entry.Name()); m != nil {
+				seen[m[1]] = true
+			}
+		}
+	}
+
+	channels := make([]string, 0, len(seen))
+	for channel := range seen {
+		channels = append(channels, channel)
+	}
+	slices.SortFunc(channels, naturalCompare)
+	return channels
+}
+
+// naturalCompare orders names with numeric suffixes numerically, so
+// "temp10" sorts after "temp2"
+func naturalCompare(a, b string) int {
+	prefixA, numA := splitNumericSuffix(a)
+	prefixB, numB := splitNumericSuffix(b)
+	if c := strings.Compare(prefixA, prefixB); c != 0 {
+		return c
+	}
+	return numA - numB
+}
+
+func splitNumericSuffix(s string) (string, int) {
+	i := len(s)
+	for i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+		i--
+	}
+	n, _ := strconv.Atoi(s[i:])
+	return s[:i], n
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNaturalCompare(t *testing.T) {
+	names := []string{"temp10", "temp2", "fan1", "temp1", "hwmon11", "hwmon3"}
+	slices.SortFunc(names, naturalCompare)
+
+	want := []string{"fan1", "hwmon3", "hwmon11", "temp1", "temp2", "temp10"}
+	if !slices.Equal(names, want) {
+		t.Errorf("sorted = %v, want %v", names, want)
+	}
+	if naturalCompare("temp10", "temp2") <= 0 {
+		t.Error("temp10 should sort after temp2")
+	}
+}
+
+func TestListHwmon(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"class/hwmon/hwmon10/name":              "nvme\n",
+		"class/hwmon/hwmon2/name":               "coretemp\n",
+		"class/hwmon/hwmon2/temp1_input":        "45000\n",
+		"class/hwmon/hwmon2/temp10_input":       "50000\n",
+		"class/hwmon/hwmon2/temp2_input":        "47000\n",
+		"class/hwmon/hwmon2/temp2_label":        "Core 0\n",
+		"class/hwmon/hwmon3/name":               "it8728\n",
+		"class/hwmon/hwmon3/device/temp1_input": "30000\n",
+	})
+
+	devices, err := listHwmon(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, dev := range devices {
+		ids = append(ids, dev.id+"="+dev.name)
+	}
+	if want := []string{"hwmon2=coretemp", "hwmon3=it8728", "hwmon10=nvme"}; !slices.Equal(ids, want) {
+		t.Fatalf("devices = %v, want %v", ids, want)
+	}
+
+	if got, want := devices[0].channels("temp", "input"), []string{"temp1", "temp2", "temp10"}; !slices.Equal(got, want) {
+		t.Errorf("channels = %v, want %v", got, want)
+	}
+	if got := devices[0].attr("temp2_label"); got != "Core 0" {
+		t.Errorf("temp2_label = %q, want %q", got, "Core 0")
+	}
+
+	// Attributes in the device subdirectory are found as well
+	if got, want := devices[1].channels("temp", "input"), []string{"temp1"}; !slices.Equal(got, want) {
+		t.Errorf("device channels = %v, want %v", got, want)
+	}
+	if value, ok := devices[1].attrInt("temp1_input"); !ok || value != 30000 {
+		t.Errorf("device temp1_input = %d, %v, want 30000, true", value, ok)
+	}
+	if _, ok := devices[1].attrInt("temp1_crit"); ok {
+		t.Error("missing temp1_crit reported as present")
+	}
+}
+
+func TestListHwmonMissing(t *testing.T) {
+	if _, err := listHwmon(t.TempDir()); err == nil {
+		t.Error("expected an error without class/hwmon")
+	}
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -129,8 +131,8 @@ func (h *HyprlandProvider) parseFocusedMonitor(monitorsJSON []byte) string {
 	return ""
 }
 
-// Listen starts listening for workspace events from Hyprland
-func (h *HyprlandProvider) Listen(emit func(dataType string, data any)) {
+// Listen listens for workspace events from Hyprland until ctx is cancelled
+func (h *HyprlandProvider) Listen(ctx context.Context, emit EmitFunc) error {
 	wrapper := types.Wrapper{
 		Type: "workspace",
 	}
@@ -138,7 +140,7 @@ func (h *HyprlandProvider) Listen(emit func(dataType string, data any)) {
 	// Get initial state
 	state, err := h.GetWorkspaceState()
 	if err != nil {
-		log.Printf("Error getting initial Hyprland workspace state: %v", err)
+		reportCollectorError("workspace", fmt.Errorf("error getting initial Hyprland workspace state: %w", err))
 	} else {
 		wrapper.Data = state
 		emit(wrapper.Type, wrapper)
@@ -146,11 +148,14 @@ func (h *HyprlandProvider) Listen(emit func(dataType string, data any)) {
 
 	f, err := h.openSocket(".socket2.sock")
 	if err != nil {
-		log.Printf("Error opening Hyprland event socket: %v", err)
-		return
+		return fmt.Errorf("error opening Hyprland event socket: %w", err)
 	}
 	defer f.Close()
 
+	// Closing the socket unblocks the scanner when ctx is cancelled
+	stop := context.AfterFunc(ctx, func() { f.Close() })
+	defer stop()
+
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -160,17 +165,27 @@ func (h *HyprlandProvider) Listen(emit func(dataType string, data any)) {
 			strings.HasPrefix(line, "focusedmon>>") {
 			state, err := h.GetWorkspaceState()
 			if err != nil {
-				log.Printf("Error getting Hyprland workspace state: %v", err)
+				reportCollectorError("workspace", fmt.Errorf("error getting Hyprland workspace state: %w", err))
 				continue
 			}
 			wrapper.Data = state
 			emit(wrapper.Type, wrapper)
 		}
 	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading Hyprland event socket: %w", err)
+	}
+	return errors.New("Hyprland event socket closed")
 }
 
 // Legacy function for backwards compatibility - wraps the provider
 func listenHyprlandEventSocket(emit func(dataType string, data any)) {
 	provider := NewHyprlandProvider()
-	provider.Listen(emit)
+	if err := provider.Listen(context.Background(), emit); err != nil {
+		log.Printf("Hyprland listener stopped: %v", err)
+	}
 }
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+// Delay between the two context switch counts taken when no previous count
+// exists
+const loadFirstSampleWindow = 250 * time.Millisecond
+
+// loadCollector reports load averages, uptime, process counts and the
+// context switch rate
+type loadCollector struct {
+	ctxt *deltaSampler[uint64, float64]
+}
+
+func init() {
+	RegisterCollector(&loadCollector{
+		ctxt: newDeltaSampler(loadFirstSampleWindow, readContextSwitches, contextSwitchRate),
+	})
+}
+
+// loadProcRoot returns proc_root of [load]
+func loadProcRoot() string {
+	return currentConfig().Section("load").String("proc_root", "/proc")
+}
+
+// readContextSwitches reads the context switch count from <proc_root>/stat
+func readContextSwitches() (uint64, error) {
+	stat, err := readProcStatCounters(filepath.Join(loadProcRoot(), "stat"))
+	if err != nil {
+		return 0, err
+	}
+	ctxt, ok := stat["ctxt"]
+	if !ok {
+		return 0, errors.New("no ctxt line in stat")
+	}
+	return ctxt, nil
+}
+
+// contextSwitchRate returns the context switches per second between two
+// counts
+func contextSwitchRate(prev, cur uint64, elapsed time.Duration) float64 {
+	if cur < prev || elapsed <= 0 {
+		return 0
+	}
+	return float64(cur-prev) / elapsed.Seconds()
+}
+
+// Name returns the data type name
+func (l *loadCollector) Name() string {
+	return "load"
+}
+
+// Interval returns the configured polling interval
+func (l *loadCollector) Interval() time.Duration {
+	return currentConfig().Section("load").Duration("interval", 3*time.Second)
+}
+
+// Snapshot returns the load with the context switch rate of the last sample
+func (l *loadCollector) Snapshot() (any, error) {
+	return l.info(l.ctxt.latest)
+}
+
+// Sample returns the load with the context switch rate since the previous
+// sample
+func (l *loadCollector) Sample() (any, error) {
+	return l.info(l.ctxt.sample)
+}
+
+// info reads /proc/loadavg, /proc/uptime and /proc/stat, and the context
+// switch rate with one of the sampler methods
+func (l *loadCollector) info(ctxtRate func(maxAge time.Duration) (float64, error)) (any, error) {
+	procRoot := loadProcRoot()
+	info := &types.LoadInfo{}
+
+	// "0.20 0.18 0.12 1/80 11206": load averages, runnable/total threads, last pid
+	loadavg, err := os.ReadFile(filepath.Join(procRoot, "loadavg"))
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(loadavg))
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("unexpected loadavg format %q", loadavg)
+	}
+	info.Load1, _ = strconv.ParseFloat(fields[0], 64)
+	info.Load5, _ = strconv.ParseFloat(fields[1], 64)
+	info.Load15, _ = strconv.ParseFloat(fields[2], 64)
+	if _, threads, ok := strings.Cut(fields[3], "/"); ok {
+		info.Threads, _ = strconv.Atoi(threads)
+	}
+
+	// "350735.47 234388.90": uptime and idle time in seconds
+	uptime, err := os.ReadFile(filepath.Join(procRoot, "uptime"))
+	if err != nil {
+		return nil, err
+	}
+	if fields := strings.Fields(string(uptime)); len(fields) > 0 {
+		info.UptimeSeconds, _ = strconv.ParseFloat(fields[0], 64)
+	}
+
+	stat, err := readProcStatCounters(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	info.Running = int(stat["procs_running"])
+	info.Blocked = int(stat["procs_blocked"])
+	info.ContextSwitchesPerSec, err = ctxtRate(2 * l.Interval())
+	if err != nil {
+		return nil, err
+	}
+
+	info.Processes, err = countProcesses(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Start emits the load every interval until ctx is cancelled
+func (l *loadCollector) Start(ctx context.Context, emit EmitFunc) error {
+	return pollCollector(ctx, l, emit)
+}
+
+// readProcStatCounters returns the single-value lines of /proc/stat such as
+// ctxt, processes, procs_running and procs_blocked
+func readProcStatCounters(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	counters := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if value, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			counters[fields[0]] = value
+		}
+	}
+	return counters, scanner.Err()
+}
+
+// countProcesses counts the numeric directories in procRoot
+func countProcesses(procRoot string) (int, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() {
+			count++
+		}
+	}
+	return count, nil
+}
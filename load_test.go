@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+func TestContextSwitchRate(t *testing.T) {
+	tests := []struct {
+		prev, cur uint64
+		elapsed   time.Duration
+		want      float64
+	}{
+		{1000, 3000, 2 * time.Second, 1000},
+		{1000, 1000, time.Second, 0},
+		{3000, 1000, time.Second, 0}, // counter reset
+		{1000, 3000, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := contextSwitchRate(tt.prev, tt.cur, tt.elapsed); got != tt.want {
+			t.Errorf("contextSwitchRate(%d, %d, %s) = %v, want %v", tt.prev, tt.cur, tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+func TestLoadCollector(t *testing.T) {
+	root := t.TempDir()
+	writeStat := func(ctxt string) {
+		writeFiles(t, root, map[string]string{
+			"stat": "cpu  1 2 3 4 5 6 7 0 0 0\nctxt " + ctxt + "\nprocesses 5000\nprocs_running 2\nprocs_blocked 1\n",
+		})
+	}
+	writeFiles(t, root, map[string]string{
+		"loadavg": "0.42 0.38 0.30 3/1450 11206\n",
+		"uptime":  "350735.47 234388.90\n",
+		"1/stat":  "",
+		"42/stat": "",
+		"self":    "",
+	})
+	writeStat("1000")
+	useConfig(t, "[load]\nproc_root = \""+filepath.ToSlash(root)+"\"\ninterval = \"1h\"\n")
+
+	l := &loadCollector{ctxt: newDeltaSampler(loadFirstSampleWindow, readContextSwitches, contextSwitchRate)}
+
+	// Without a previous count the first sample waits for a window of its own
+	data, err := l.Sample()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := data.(*types.LoadInfo)
+	want := types.LoadInfo{Load1: 0.42, Load5: 0.38, Load15: 0.3, UptimeSeconds: 350735.47, Processes: 2, Threads: 1450, Running: 2, Blocked: 1}
+	if *info != want {
+		t.Errorf("first sample = %+v, want %+v", *info, want)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	writeStat("2000")
+	data, err = l.Sample()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rate := data.(*types.LoadInfo).ContextSwitchesPerSec
+	if rate <= 0 || rate > 10000 {
+		t.Fatalf("second sample rate = %v, want between 0 and 10000", rate)
+	}
+
+	// Snapshots repeat the rate of the last sample instead of moving its
+	// window
+	writeStat("900000")
+	for range 2 {
+		data, err = l.Snapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := data.(*types.LoadInfo).ContextSwitchesPerSec; got != rate {
+			t.Errorf("snapshot rate = %v, want %v", got, rate)
+		}
+	}
+	data, _ = l.Sample()
+	if got := data.(*types.LoadInfo).ContextSwitchesPerSec; got <= rate || math.IsInf(got, 0) {
+		t.Errorf("sample after snapshots = %v, want above %v", got, rate)
+	}
+}
@@ -7,28 +7,16 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 )
 
-var socketPath = "/tmp/system-info-provider.sock"
-
 // ----- emitToConsole updates to stdout -----
 func emitToConsole(dataType string, data any) {
 	dataJSON, _ := json.Marshal(data)
 	fmt.Printf("\r%s", string(dataJSON))
 }
 
-// listenWorkspaceEvents starts the appropriate workspace provider based on detected compositor
-func listenWorkspaceEvents(emit func(dataType string, data any)) {
-	provider := NewWorkspaceProvider()
-	if provider == nil {
-		log.Println("No supported compositor detected. Workspace events disabled.")
-		return
-	}
-	log.Printf("Detected compositor: %s", provider.Name())
-	provider.Listen(emit)
-}
-
 // ----- main -----
 func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
@@ -40,28 +28,59 @@ func main() {
 	}
 	requestedData := args[1]
 
+	if requestedData == "doctor" {
+		os.Exit(runDoctor())
+	}
+
+	if err := loadConfig(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Reload the config on SIGHUP without touching connected clients
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reloadConfig()
+		}
+	}()
+
+	var collectors sync.WaitGroup
 	switch requestedData {
-	case "workspace":
-		go listenWorkspaceEvents(emitToConsole)
-	case "hyprland":
-		// Legacy: still supported for backwards compatibility
-		go listenWorkspaceEvents(emitToConsole)
-	case "system":
-		go sysInfoLoop(emitToConsole)
-	case "bluetooth":
-		go listenForBluetoothChanges(emitToConsole)
 	case "socket":
-		_, err := connectToSocket(socketPath)
+		_, err := connectToSocket(currentConfig().SocketPath())
 		if err != nil {
 			log.Fatalf("Failed to connect to socket: %v", err)
 		}
-		go sysInfoLoop(broadcast)
-		go listenWorkspaceEvents(broadcast)
-		go listenForBluetoothChanges(broadcast)
+		// Collectors are started when clients subscribe to them
+		initCollectors(ctx, broadcast)
+		onConfigLoad(syncCollectors)
+		go keepChargeThresholds(ctx)
 	default:
-		log.Fatalf("Unknown requested data type: %s", requestedData)
+		collector, ok := lookupCollector(requestedData)
+		if !ok {
+			log.Fatalf("Unknown requested data type: %s", requestedData)
+		}
+		// On-demand types are printed once
+		if _, ok := collector.(OnDemand); ok {
+			data, err := snapshotCollector(collector)
+			if data == nil {
+				log.Fatalf("Failed to read %s: %v", collector.Name(), err)
+			}
+			emitToConsole(collector.Name(), wrapData(collector, data))
+			fmt.Println()
+			return
+		}
+		collectors.Go(func() {
+			superviseCollector(ctx, collector, emitToConsole)
+		})
 	}
 	log.Println("Daemon started. Press Ctrl+C to exit.")
 	<-ctx.Done()
 	log.Println("Shutting down daemon.")
+
+	// Collectors stop their plugin processes on cancel; wait for them so
+	// nothing outlives the daemon
+	collectors.Wait()
+	waitCollectors()
 }
@@ -2,7 +2,9 @@ package main
 
 import (
 	"bufio"
-	"log"
+	"context"
+	"errors"
+	"fmt"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -101,8 +103,8 @@ func (m *MangoProvider) parseTagOutput(output string) (*types.WorkspaceInfo, err
 	return info, nil
 }
 
-// Listen starts listening for workspace events from Mango WC
-func (m *MangoProvider) Listen(emit func(dataType string, data any)) {
+// Listen listens for workspace events from Mango WC until ctx is cancelled
+func (m *MangoProvider) Listen(ctx context.Context, emit EmitFunc) error {
 	wrapper := types.Wrapper{
 		Type: "workspace",
 	}
@@ -129,22 +131,20 @@ func (m *MangoProvider) Listen(emit func(dataType string, data any)) {
 	// Get initial state
 	state, err := m.GetWorkspaceState()
 	if err != nil {
-		log.Printf("Error getting initial Mango workspace state: %v", err)
+		reportCollectorError("workspace", fmt.Errorf("error getting initial Mango workspace state: %w", err))
 	} else {
 		emitIfChanged(state)
 	}
 
 	// Start watching for changes with mmsg -w -t
-	cmd := exec.Command("mmsg", "-w", "-t")
+	cmd := exec.CommandContext(ctx, "mmsg", "-w", "-t")
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		log.Printf("Error creating Mango watch pipe: %v", err)
-		return
+		return fmt.Errorf("error creating Mango watch pipe: %w", err)
 	}
 
 	if err := cmd.Start(); err != nil {
-		log.Printf("Error starting Mango watch: %v", err)
-		return
+		return fmt.Errorf("error starting Mango watch: %w", err)
 	}
 
 	scanner := bufio.NewScanner(stdout)
@@ -152,13 +152,22 @@ func (m *MangoProvider) Listen(emit func(dataType string, data any)) {
 		// On any output, refresh the workspace state
 		state, err := m.GetWorkspaceState()
 		if err != nil {
-			log.Printf("Error getting Mango workspace state: %v", err)
+			reportCollectorError("workspace", fmt.Errorf("error getting Mango workspace state: %w", err))
 			continue
 		}
 		emitIfChanged(state)
 	}
 
 	if err := scanner.Err(); err != nil {
-		log.Printf("Error reading Mango watch output: %v", err)
+		reportCollectorError("workspace", fmt.Errorf("error reading Mango watch output: %w", err))
 	}
+
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("mmsg -w exited: %w", err)
+	}
+	return errors.New("mmsg -w exited")
 }
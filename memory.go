@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+// readMemoryInfo returns RAM and swap usage and the state of every zram
+// device under <sysRoot>/block
+func readMemoryInfo(sysRoot string) (*types.MemoryInfo, error) {
+	vm, err := mem.VirtualMemory()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &types.MemoryInfo{
+		Total:     vm.Total,
+		Available: vm.Available,
+		Free:      vm.Free,
+		Cached:    vm.Cached,
+		Buffers:   vm.Buffers,
+		Shared:    vm.Shared,
+		Dirty:     vm.Dirty,
+		SwapTotal: vm.SwapTotal,
+	}
+	if vm.Available <= vm.Total {
+		info.Used = vm.Total - vm.Available
+	}
+	if vm.Total > 0 {
+		info.UsedPercent = float64(info.Used) / float64(vm.Total) * 100
+	}
+	if vm.SwapFree <= vm.SwapTotal {
+		info.SwapUsed = vm.SwapTotal - vm.SwapFree
+	}
+
+	info.Zram, err = readZramDevices(sysRoot)
+	return info, err
+}
+
+// readZramDevices reads mm_stat of every initialised zram device
+func readZramDevices(sysRoot string) ([]types.ZramDevice, error) {
+	paths, err := filepath.Glob(filepath.Join(sysRoot, "block", "zram*"))
+	if err != nil {
+		return nil, err
+	}
+
+	devices := []types.ZramDevice{}
+	for _, dir := range paths {
+		disksize, _ := strconv.ParseUint(readSysfsString(filepath.Join(dir, "disksize")), 10, 64)
+		if disksize == 0 {
+			// Reset or never configured
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "mm_stat"))
+		if err != nil {
+			return devices, err
+		}
+		// orig_data_size compr_data_size mem_used_total mem_limit mem_used_max ...
+		fields := strings.Fields(string(data))
+		if len(fields) < 3 {
+			return devices, fmt.Errorf("unexpected mm_stat format in %s", dir)
+		}
+		var values [3]uint64
+		for i := range values {
+			values[i], err = strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				return devices, fmt.Errorf("parse %s/mm_stat: %w", dir, err)
+			}
+		}
+		device := types.ZramDevice{
+			Name:           filepath.Base(dir),
+			DiskSize:       disksize,
+			OriginalSize:   values[0],
+			CompressedSize: values[1],
+			MemoryUsed:     values[2],
+		}
+		if device.CompressedSize > 0 {
+			device.CompressionRatio = float64(device.OriginalSize) / float64(device.CompressedSize)
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// openNetlink opens a netlink socket of the given protocol subscribed to the
+// multicast groups. The socket is non-blocking and wrapped in an os.File, so
+// closing the file unblocks a pending Read.
+func openNetlink(protocol int, groups uint32) (*os.File, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_DGRAM|unix.SOCK_NONBLOCK|unix.SOCK_CLOEXEC, protocol)
+	if err != nil {
+		return nil, fmt.Errorf("open netlink socket: %w", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Groups: groups}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("bind netlink socket: %w", err)
+	}
+	return os.NewFile(uintptr(fd), "netlink"), nil
+}
+
+// netlinkMessages splits a buffer received from a netlink socket into the
+// payloads of its messages
+func netlinkMessages(buf []byte) [][]byte {
+	var payloads [][]byte
+	for len(buf) >= unix.SizeofNlMsghdr {
+		length := int(binary.NativeEndian.Uint32(buf[0:4]))
+		if length < unix.SizeofNlMsghdr || length > len(buf) {
+			break
+		}
+		payloads = append(payloads, buf[unix.SizeofNlMsghdr:length])
+		// Messages are aligned to 4 bytes
+		aligned := (length + unix.NLMSG_ALIGNTO - 1) &^ (unix.NLMSG_ALIGNTO - 1)
+		if aligned >= len(buf) {
+			break
+		}
+		buf = buf[aligned:]
+	}
+	return payloads
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Plugin modes
+const (
+	// pluginModeStream runs a long-lived process that prints one JSON value per line
+	pluginModeStream = "stream"
+	// pluginModeInterval runs a command every interval and publishes its output
+	pluginModeInterval = "interval"
+)
+
+// Longest line a stream plugin may print
+const pluginMaxLine = 1 << 20
+
+// How long to wait for the output pipes after a plugin was killed, in case
+// a process outside its process group still holds them open
+const pluginWaitDelay = 2 * time.Second
+
+// pluginCollector publishes the output of an external command configured
+// in a [[plugin]] table, e.g.
+//
+//	[[plugin]]
+//	name = "mail"
+//	command = ["notmuch", "count", "tag:unread"]
+//	mode = "interval"
+//	interval = "30s"
+type pluginCollector struct {
+	name     string
+	command  []string
+	mode     string
+	interval time.Duration
+
+	mu   sync.Mutex
+	last json.RawMessage // last value printed by the command
+}
+
+func init() {
+	onConfigLoad(registerPlugins)
+}
+
+// registerPlugins registers a collector for every enabled [[plugin]] table
+func registerPlugins(cfg *Config) {
+	registerDefined(cfg, "plugin", func(section Section) (definedCollector, error) {
+		return newPluginCollector(section)
+	})
+}
+
+// newPluginCollector validates a [[plugin]] table
+func newPluginCollector(section Section) (*pluginCollector, error) {
+	name := strings.ToLower(section.String("name", ""))
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return nil, fmt.Errorf("invalid plugin name %q", name)
+	}
+
+	// The command is either an argv array or a shell command line
+	command := section.Strings("command", nil)
+	if command == nil {
+		if line := section.String("command", ""); line != "" {
+			command = []string{"sh", "-c", line}
+		}
+	}
+	if len(command) == 0 {
+		return nil, fmt.Errorf("plugin %s has no command", name)
+	}
+
+	mode := section.String("mode", pluginModeStream)
+	if mode != pluginModeStream && mode != pluginModeInterval {
+		return nil, fmt.Errorf("plugin %s has unknown mode %q", name, mode)
+	}
+
+	return &pluginCollector{
+		name:     name,
+		command:  command,
+		mode:     mode,
+		interval: section.Duration("interval", 30*time.Second),
+	}, nil
+}
+
+func (p *pluginCollector) definition() string {
+	return fmt.Sprintf("%q %s %s", p.command, p.mode, p.interval)
+}
+
+// Name returns the data type name
+func (p *pluginCollector) Name() string {
+	return p.name
+}
+
+// Interval returns the command interval, or zero for stream plugins
+func (p *pluginCollector) Interval() time.Duration {
+	if p.mode == pluginModeStream {
+		return 0
+	}
+	return p.interval
+}
+
+// Snapshot returns the last value printed by the command
+func (p *pluginCollector) Snapshot() (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.last == nil {
+		return nil, fmt.Errorf("plugin %s has not produced output yet", p.name)
+	}
+	return p.last, nil
+}
+
+// Start runs the plugin until ctx is cancelled
+func (p *pluginCollector) Start(ctx context.Context, emit EmitFunc) error {
+	if p.mode == pluginModeInterval {
+		return p.runInterval(ctx, emit)
+	}
+	return p.runStream(ctx, emit)
+}
+
+// runInterval runs the command every interval and publishes the last line
+// of its output
+func (p *pluginCollector) runInterval(ctx context.Context, emit EmitFunc) error {
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, p.interval)
+		cmd := p.cmd(runCtx)
+		out, err := cmd.Output()
+		cancel()
+		// Background processes left by the command would outlive it, and
+		// may have kept stdout open after the command itself succeeded
+		killProcessGroup(cmd)
+		if errors.Is(err, exec.ErrWaitDelay) {
+			err = nil
+		}
+
+		if err != nil {
+			reportCollectorError(p.name, fmt.Errorf("plugin %s failed: %w", p.name, err))
+		} else if lines := strings.Split(strings.TrimSpace(string(out)), "\n"); lines[0] != "" {
+			p.publish(lines[len(lines)-1], emit)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(p.interval):
+		}
+	}
+}
+
+// runStream runs the plugin process and publishes every line it prints.
+// When the process exits, the supervisor restarts it with backoff.
+func (p *pluginCollector) runStream(ctx context.Context, emit EmitFunc) error {
+	cmd := p.cmd(ctx)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), pluginMaxLine)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			p.publish(line, emit)
+		}
+	}
+
+	// Reading stops on an overlong line or when the plugin closes stdout,
+	// but the process may keep running and Wait would block forever
+	scanErr := scanner.Err()
+	killProcessGroup(cmd)
+	waitErr := cmd.Wait()
+	if scanErr != nil {
+		return fmt.Errorf("plugin %s: read output: %w", p.name, scanErr)
+	}
+	if waitErr != nil {
+		return fmt.Errorf("plugin %s exited: %w", p.name, waitErr)
+	}
+	return fmt.Errorf("plugin %s exited", p.name)
+}
+
+// cmd returns the plugin command. It runs in its own process group, so
+// cancelling ctx kills the processes it started as well, and it is killed
+// when the daemon dies.
+func (p *pluginCollector) cmd(ctx context.Context) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, p.command[0], p.command[1:]...)
+	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pdeathsig: syscall.SIGKILL}
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+	cmd.WaitDelay = pluginWaitDelay
+	return cmd
+}
+
+// killProcessGroup kills the process group of a started plugin command
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	if errors.Is(err, syscall.ESRCH) {
+		return os.ErrProcessDone
+	}
+	return err
+}
+
+// publish caches and emits a line of plugin output. Lines that are not
+// valid JSON are published as JSON strings.
+func (p *pluginCollector) publish(line string, emit EmitFunc) {
+	value := jsonValue(line)
+
+	p.mu.Lock()
+	p.last = value
+	p.mu.Unlock()
+
+	emit(p.name, wrapData(p, value))
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// processGone reports whether pid has exited. Orphans may stay zombies
+// when nothing reaps them, so a zombie counts as gone.
+func processGone(pid int) bool {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true
+	}
+	// The state follows the command name, which is in parentheses
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && (fields[0] == "Z" || fields[0] == "X")
+}
+
+// waitGone waits until pid has exited
+func waitGone(t *testing.T, pid int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !processGone(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("process %d is still running", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// startPlugin runs p until the returned cancel function is called and
+// sends every emitted value to the returned channel
+func startPlugin(t *testing.T, p *pluginCollector) (<-chan any, func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	values := make(chan any, 16)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Start(ctx, func(_ string, data any) {
+			values <- data
+		})
+	}()
+
+	stop := func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("plugin did not stop after cancel")
+		}
+	}
+	t.Cleanup(stop)
+	return values, stop
+}
+
+// receivePID waits for the plugin to print the PID of its child
+func receivePID(t *testing.T, p *pluginCollector, values <-chan any) int {
+	t.Helper()
+	select {
+	case <-values:
+	case <-time.After(5 * time.Second):
+		t.Fatal("plugin printed nothing")
+	}
+	last, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(string(last.(json.RawMessage)))
+	if err != nil {
+		t.Fatalf("plugin printed %s: %v", last, err)
+	}
+	return pid
+}
+
+func TestPluginStreamKillsChildrenOnCancel(t *testing.T) {
+	p := &pluginCollector{
+		name:    "test",
+		command: []string{"sh", "-c", "sleep 60 & echo $!; wait"},
+		mode:    pluginModeStream,
+	}
+	values, stop := startPlugin(t, p)
+	pid := receivePID(t, p, values)
+
+	stop()
+	waitGone(t, pid)
+}
+
+func TestPluginIntervalKillsChildrenHoldingStdout(t *testing.T) {
+	// The background sleep keeps stdout open after the shell exits
+	p := &pluginCollector{
+		name:     "test",
+		command:  []string{"sh", "-c", "sleep 60 & echo $!"},
+		mode:     pluginModeInterval,
+		interval: time.Hour,
+	}
+	values, stop := startPlugin(t, p)
+	pid := receivePID(t, p, values)
+	waitGone(t, pid)
+
+	stop()
+}
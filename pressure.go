@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+	"golang.org/x/sys/unix"
+)
+
+// pressureCollector reports Pressure Stall Information. Besides polling, it
+// can register PSI triggers so that stalls are published as soon as the
+// kernel notices them:
+//
+//	[pressure]
+//	triggers = ["memory some 150ms 2s", "io full 500ms 2s"]
+//
+// A trigger fires when tasks stalled for at least the given time within the
+// window. Unprivileged processes need a window that is a multiple of 2s.
+type pressureCollector struct{}
+
+func init() {
+	RegisterCollector(&pressureCollector{})
+}
+
+// pressureTrigger is a parsed entry of the triggers setting
+type pressureTrigger struct {
+	spec     string // as configured
+	resource string // cpu, memory or io
+	kind     string // some or full
+	stall    time.Duration
+	window   time.Duration
+}
+
+// Name returns the data type name
+func (p *pressureCollector) Name() string {
+	return "pressure"
+}
+
+// Interval returns the configured polling interval
+func (p *pressureCollector) Interval() time.Duration {
+	return currentConfig().Section("pressure").Duration("interval", 3*time.Second)
+}
+
+// Snapshot reads /proc/pressure/{cpu,memory,io}
+func (p *pressureCollector) Snapshot() (any, error) {
+	dir := filepath.Join(currentConfig().Section("pressure").String("proc_root", "/proc"), "pressure")
+
+	var info types.PressureInfo
+	var err error
+	if info.CPU, err = readPressure(filepath.Join(dir, "cpu")); err != nil {
+		return nil, err
+	}
+	if info.Memory, err = readPressure(filepath.Join(dir, "memory")); err != nil {
+		return nil, err
+	}
+	if info.IO, err = readPressure(filepath.Join(dir, "io")); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Start emits the pressure every interval and whenever a trigger fires,
+// until ctx is cancelled. If the triggers cannot be registered, the error is
+// reported and the collector only polls.
+func (p *pressureCollector) Start(ctx context.Context, emit EmitFunc) error {
+	settings := currentConfig().Section("pressure")
+	triggers, err := parsePressureTriggers(settings.Strings("triggers", nil))
+	if err != nil {
+		reportCollectorError(p.Name(), err)
+		return pollCollector(ctx, p, emit)
+	}
+	if len(triggers) == 0 {
+		return pollCollector(ctx, p, emit)
+	}
+
+	files, err := openPressureTriggers(filepath.Join(settings.String("proc_root", "/proc"), "pressure"), triggers)
+	if err != nil {
+		reportCollectorError(p.Name(), fmt.Errorf("polling only: %w", err))
+		return pollCollector(ctx, p, emit)
+	}
+
+	fired := make(chan string)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- watchPressureTriggers(ctx, files, triggers, fired)
+	}()
+
+	publish := func(trigger string) {
+		data, err := p.Snapshot()
+		if err != nil {
+			reportCollectorError(p.Name(), err)
+			return
+		}
+		data.(*types.PressureInfo).Trigger = trigger
+		emit(p.Name(), wrapData(p, data))
+	}
+
+	publish("")
+	for {
+		select {
+		case <-ctx.Done():
+			<-watchErr
+			return nil
+		case err := <-watchErr:
+			return err
+		case trigger := <-fired:
+			publish(trigger)
+		case <-time.After(p.Interval()):
+			publish("")
+		}
+	}
+}
+
+// readPressure parses a /proc/pressure file
+func readPressure(path string) (types.PressureResource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return types.PressureResource{}, err
+	}
+	defer f.Close()
+
+	var resource types.PressureResource
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		var stall types.PressureStall
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			switch key {
+			case "avg10":
+				stall.Avg10, _ = strconv.ParseFloat(value, 64)
+			case "avg60":
+				stall.Avg60, _ = strconv.ParseFloat(value, 64)
+			case "avg300":
+				stall.Avg300, _ = strconv.ParseFloat(value, 64)
+			case "total":
+				stall.Total, _ = strconv.ParseUint(value, 10, 64)
+			}
+		}
+		switch fields[0] {
+		case "some":
+			resource.Some = stall
+		case "full":
+			resource.Full = &stall
+		}
+	}
+	return resource, scanner.Err()
+}
+
+// parsePressureTriggers parses "<resource> some|full <stall> <window>" specs
+func parsePressureTriggers(specs []string) ([]pressureTrigger, error) {
+	var triggers []pressureTrigger
+	for _, spec := range specs {
+		fields := strings.Fields(spec)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("invalid pressure trigger %q, expected \"<resource> some|full <stall> <window>\"", spec)
+		}
+		trigger := pressureTrigger{spec: spec, resource: fields[0], kind: fields[1]}
+		switch trigger.resource {
+		case "cpu", "memory", "io":
+		default:
+			return nil, fmt.Errorf("pressure trigger %q: unknown resource %q", spec, trigger.resource)
+		}
+		if trigger.kind != "some" && trigger.kind != "full" {
+			return nil, fmt.Errorf("pressure trigger %q: expected some or full, got %q", spec, trigger.kind)
+		}
+		var err error
+		if trigger.stall, err = time.ParseDuration(fields[2]); err != nil {
+			return nil, fmt.Errorf("pressure trigger %q: %w", spec, err)
+		}
+		if trigger.window, err = time.ParseDuration(fields[3]); err != nil {
+			return nil, fmt.Errorf("pressure trigger %q: %w", spec, err)
+		}
+		if trigger.stall <= 0 || trigger.stall > trigger.window {
+			return nil, fmt.Errorf("pressure trigger %q: stall must be positive and within the window", spec)
+		}
+		triggers = append(triggers, trigger)
+	}
+	return triggers, nil
+}
+
+// openPressureTriggers registers every trigger with the kernel. Each trigger
+// needs its own file descriptor, it is removed when the fd is closed.
+func openPressureTriggers(dir string, triggers []pressureTrigger) ([]*os.File, error) {
+	var files []*os.File
+	for _, trigger := range triggers {
+		f, err := os.OpenFile(filepath.Join(dir, trigger.resource), os.O_RDWR, 0)
+		if err == nil {
+			// "some 150000 1000000" with stall and window in microseconds,
+			// the kernel expects the terminating NUL
+			request := fmt.Sprintf("%s %d %d\x00", trigger.kind, trigger.stall.Microseconds(), trigger.window.Microseconds())
+			if _, err = f.Write([]byte(request)); err != nil {
+				f.Close()
+			}
+		}
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			return nil, fmt.Errorf("register pressure trigger %q: %w", trigger.spec, err)
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// watchPressureTriggers sends the spec of every trigger that fires to fired
+// until ctx is cancelled, then closes files
+func watchPressureTriggers(ctx context.Context, files []*os.File, triggers []pressureTrigger, fired chan<- string) error {
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	wake, err := openCancelEventfd(ctx)
+	if err != nil {
+		return err
+	}
+	defer wake.Close()
+
+	fds := make([]unix.PollFd, len(files), len(files)+1)
+	for i, f := range files {
+		fds[i] = unix.PollFd{Fd: int32(f.Fd()), Events: unix.POLLPRI}
+	}
+	fds = append(fds, wake.pollFd())
+
+	for ctx.Err() == nil {
+		n, err := unix.Poll(fds, -1)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("poll pressure triggers: %w", err)
+		}
+		for i := 0; n > 0 && i < len(files); i++ {
+			switch {
+			case fds[i].Revents&unix.POLLERR != 0:
+				return fmt.Errorf("pressure trigger %q was removed by the kernel", triggers[i].spec)
+			case fds[i].Revents&unix.POLLPRI != 0:
+				select {
+				case fired <- triggers[i].spec:
+				case <-ctx.Done():
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+func TestReadPressure(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		// Older kernels have no full line for cpu
+		"cpu": "some avg10=1.50 avg60=0.75 avg300=0.25 total=123456\n",
+		"memory": "some avg10=0.00 avg60=0.10 avg300=0.05 total=2000\n" +
+			"full avg10=0.00 avg60=0.02 avg300=0.01 total=500\n",
+	})
+
+	cpu, err := readPressure(filepath.Join(root, "cpu"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := types.PressureStall{Avg10: 1.5, Avg60: 0.75, Avg300: 0.25, Total: 123456}
+	if cpu.Some != want || cpu.Full != nil {
+		t.Errorf("cpu = %+v (full %v), want some %+v without full", cpu.Some, cpu.Full, want)
+	}
+
+	memory, err := readPressure(filepath.Join(root, "memory"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFull := types.PressureStall{Avg60: 0.02, Avg300: 0.01, Total: 500}
+	if memory.Some.Total != 2000 || memory.Full == nil || *memory.Full != wantFull {
+		t.Errorf("memory = %+v (full %v), want full %+v", memory.Some, memory.Full, wantFull)
+	}
+
+	if _, err := readPressure(filepath.Join(root, "io")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestParsePressureTriggers(t *testing.T) {
+	triggers, err := parsePressureTriggers([]string{"memory some 150ms 2s", "io  full 500ms   2s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []pressureTrigger{
+		{spec: "memory some 150ms 2s", resource: "memory", kind: "some", stall: 150 * time.Millisecond, window: 2 * time.Second},
+		{spec: "io  full 500ms   2s", resource: "io", kind: "full", stall: 500 * time.Millisecond, window: 2 * time.Second},
+	}
+	if len(triggers) != len(want) {
+		t.Fatalf("triggers = %+v, want %+v", triggers, want)
+	}
+	for i := range want {
+		if triggers[i] != want[i] {
+			t.Errorf("trigger %d = %+v, want %+v", i, triggers[i], want[i])
+		}
+	}
+
+	invalid := map[string]string{
+		"memory some 150ms":      "expected",
+		"disk some 150ms 2s":     "unknown resource",
+		"cpu most 150ms 2s":      "expected some or full",
+		"cpu some soon 2s":       "invalid duration",
+		"cpu some 150ms 2 hours": "expected",
+		"cpu some 3s 2s":         "within the window",
+		"cpu some 0s 2s":         "positive",
+	}
+	for spec, msg := range invalid {
+		_, err := parsePressureTriggers([]string{"memory some 150ms 2s", spec})
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("parsePressureTriggers(%q) = %v, want an error containing %q", spec, err, msg)
+		}
+	}
+}
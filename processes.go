@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+// Longest command line reported, arguments beyond are cut off
+const maxCmdlineLength = 256
+
+// Window measured when the previous sample is missing or too old to explain
+// the current load
+const (
+	processSampleWindow = 500 * time.Millisecond
+	processSampleMaxAge = 10 * time.Second
+)
+
+// processStat holds the fields of /proc/[pid]/stat used for the top lists
+type processStat struct {
+	pid     int
+	command string
+	uid     uint32
+	ticks   uint64 // user and system time in clock ticks
+	rss     uint64 // resident pages
+}
+
+// readProcessStats reads every process in procRoot. Processes that exit
+// while being read are skipped.
+func readProcessStats(procRoot string) (map[int]processStat, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[int]processStat, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		dir := filepath.Join(procRoot, entry.Name())
+		data, err := os.ReadFile(filepath.Join(dir, "stat"))
+		if err != nil {
+			continue
+		}
+
+		// pid (comm) state ppid ... The command may contain spaces and
+		// parentheses, so split at the last ')'
+		line := string(data)
+		open, end := strings.IndexByte(line, '('), strings.LastIndexByte(line, ')')
+		if open < 0 || end < open {
+			continue
+		}
+		fields := strings.Fields(line[end+1:])
+		if len(fields) < 22 {
+			continue
+		}
+		utime, _ := strconv.ParseUint(fields[11], 10, 64)
+		stime, _ := strconv.ParseUint(fields[12], 10, 64)
+		rss, _ := strconv.ParseInt(fields[21], 10, 64)
+
+		stat := processStat{pid: pid, command: line[open+1 : end], ticks: utime + stime, rss: uint64(max(rss, 0))}
+		if info, err := os.Stat(dir); err == nil {
+			if st, ok := info.Sys().(*syscall.Stat_t); ok {
+				stat.uid = st.Uid
+			}
+		}
+		stats[pid] = stat
+	}
+	return stats, nil
+}
+
+// processSample is one read of /proc/stat and every /proc/[pid]/stat
+type processSample struct {
+	stats map[int]processStat
+	total cpuTimes
+	cores int
+}
+
+// processUsage is the CPU percentage of each process between two samples,
+// with the stats of the later one
+type processUsage struct {
+	stats map[int]processStat
+	usage map[int]float64
+}
+
+// readProcessSample reads the processes below <proc_root> as set in
+// [processes]
+func readProcessSample() (processSample, error) {
+	procRoot := currentConfig().Section("processes").String("proc_root", "/proc")
+	total, perCore, err := readCPUTimes(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return processSample{}, err
+	}
+	stats, err := readProcessStats(procRoot)
+	return processSample{stats: stats, total: total, cores: max(len(perCore), 1)}, err
+}
+
+// processCPUUsage computes the CPU percentage of each process between two
+// samples
+func processCPUUsage(prev, cur processSample, _ time.Duration) processUsage {
+	// Jiffies of all cores, so a process saturating one core is at 100%
+	elapsed := float64(cur.total.total()) - float64(prev.total.total())
+	usage := make(map[int]float64, len(cur.stats))
+	for pid, stat := range cur.stats {
+		prevStat, ok := prev.stats[pid]
+		if !ok || elapsed <= 0 || stat.ticks < prevStat.ticks {
+			continue
+		}
+		usage[pid] = float64(stat.ticks-prevStat.ticks) / elapsed * float64(cur.cores) * 100
+	}
+	return processUsage{stats: cur.stats, usage: usage}
+}
+
+// processesCollector lists the processes with the highest CPU and memory
+// usage. Reading every process is too expensive to do constantly, so it is
+// only available through GET.
+type processesCollector struct {
+	sampler *deltaSampler[processSample, processUsage]
+	users   sync.Map // uid → user name
+}
+
+func init() {
+	RegisterCollector(&processesCollector{
+		sampler: newDeltaSampler(processSampleWindow, readProcessSample, processCPUUsage),
+	})
+}
+
+// Name returns the data type name
+func (p *processesCollector) Name() string {
+	return "processes"
+}
+
+// Interval returns the polling interval used if the collector is started
+func (p *processesCollector) Interval() time.Duration {
+	return currentConfig().Section("processes").Duration("interval", 10*time.Second)
+}
+
+// OnDemand marks the collector as GET only
+func (p *processesCollector) OnDemand() {}
+
+// Snapshot returns the top processes by CPU usage since the previous
+// snapshot and by resident memory
+func (p *processesCollector) Snapshot() (any, error) {
+	settings := currentConfig().Section("processes")
+	procRoot := settings.String("proc_root", "/proc")
+	count := settings.Int("count", 5)
+	if count < 1 {
+		count = 5
+	}
+
+	sample, err := p.sampler.sample(processSampleMaxAge)
+	if err != nil {
+		return nil, err
+	}
+	stats, usage := sample.stats, sample.usage
+	var memTotal uint64
+	if vm, err := mem.VirtualMemory(); err == nil {
+		memTotal = vm.Total
+	}
+	pageSize := uint64(os.Getpagesize())
+
+	pids := make([]int, 0, len(stats))
+	for pid := range stats {
+		pids = append(pids, pid)
+	}
+	top := func(value func(pid int) float64) []types.ProcessInfo {
+		slices.SortFunc(pids, func(a, b int) int {
+			if va, vb := value(a), value(b); va != vb {
+				if va > vb {
+					return -1
+				}
+				return 1
+			}
+			return a - b
+		})
+		list := []types.ProcessInfo{}
+		for _, pid := range pids[:min(count, len(pids))] {
+			stat := stats[pid]
+			info := types.ProcessInfo{
+				PID:        pid,
+				Command:    stat.command,
+				Cmdline:    readCmdline(procRoot, pid),
+				User:       p.userName(stat.uid),
+				CPUPercent: usage[pid],
+				RSS:        stat.rss * pageSize,
+			}
+			if memTotal > 0 {
+				info.MemoryPercent = float64(info.RSS) / float64(memTotal) * 100
+			}
+			list = append(list, info)
+		}
+		return list
+	}
+
+	return &types.ProcessesInfo{
+		ByCPU:    top(func(pid int) float64 { return usage[pid] }),
+		ByMemory: top(func(pid int) float64 { return float64(stats[pid].rss) }),
+	}, nil
+}
+
+// Start emits the top processes every interval until ctx is cancelled
+func (p *processesCollector) Start(ctx context.Context, emit EmitFunc) error {
+	return pollCollector(ctx, p, emit)
+}
+
+// userName resolves a uid, falling back to the number
+func (p *processesCollector) userName(uid uint32) string {
+	if name, ok := p.users.Load(uid); ok {
+		return name.(string)
+	}
+	name := strconv.FormatUint(uint64(uid), 10)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	p.users.Store(uid, name)
+	return name
+}
+
+// readCmdline returns the command line of a process with arguments joined by
+// spaces, or "" for kernel threads
+func readCmdline(procRoot string, pid int) string {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return ""
+	}
+	cmdline := strings.TrimSpace(strings.ReplaceAll(string(data), "\x00", " "))
+	if len(cmdline) > maxCmdlineLength {
+		cmdline = strings.ToValidUTF8(cmdline[:maxCmdlineLength], "")
+	}
+	return cmdline
+}
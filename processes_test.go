@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+func TestProcessesCount(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{"stat": "cpu  100 0 100 1000 0 0 0 0 0 0\ncpu0 100 0 100 1000 0 0 0 0 0 0\n"}
+	for pid := 1; pid <= 7; pid++ {
+		// pid (comm) state ppid pgrp session tty_nr tpgid flags minflt cminflt
+		// majflt cmajflt utime stime cutime cstime priority nice num_threads
+		// itrealvalue starttime vsize rss
+		files[strconv.Itoa(pid)+"/stat"] = fmt.Sprintf("%d (proc %d) S 1 1 1 0 -1 0 0 0 0 0 %d 0 0 0 20 0 1 0 0 0 %d\n", pid, pid, pid, pid*100)
+		files[strconv.Itoa(pid)+"/cmdline"] = fmt.Sprintf("proc\x00--id\x00%d\x00", pid)
+	}
+	writeFiles(t, root, files)
+
+	c := &processesCollector{sampler: newDeltaSampler(0, readProcessSample, processCPUUsage)}
+	tests := []struct {
+		count string
+		want  int
+	}{
+		{"", 5},
+		{"count = 2", 2},
+		{"count = 10", 7},
+		{"count = 0", 5},
+		{"count = -3", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.count, func(t *testing.T) {
+			useConfig(t, "[processes]\nproc_root = \""+root+"\"\n"+tt.count+"\n")
+			data, err := c.Snapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			info := data.(*types.ProcessesInfo)
+			if len(info.ByCPU) != tt.want || len(info.ByMemory) != tt.want {
+				t.Fatalf("got %d by CPU and %d by memory, want %d", len(info.ByCPU), len(info.ByMemory), tt.want)
+			}
+			// Largest RSS first
+			if top := info.ByMemory[0]; top.PID != 7 || top.Command != "proc 7" || top.Cmdline != "proc --id 7" {
+				t.Errorf("top process by memory = %+v, want pid 7", top)
+			}
+		})
+	}
+}
+
+func TestProcessesCPUPercent(t *testing.T) {
+	root := t.TempDir()
+	// writeState writes /proc/stat with two cores and the given jiffies,
+	// and a process with the given CPU ticks for every pid
+	writeState := func(jiffies int, ticks map[int]int) {
+		files := map[string]string{
+			"stat": fmt.Sprintf("cpu  %d 0 0 %d 0 0 0 0 0 0\ncpu0 0 0 0 0 0 0 0 0 0 0\ncpu1 0 0 0 0 0 0 0 0 0 0\n", jiffies/4, jiffies-jiffies/4),
+		}
+		for pid, n := range ticks {
+			files[strconv.Itoa(pid)+"/stat"] = fmt.Sprintf("%d (proc %d) S 1 1 1 0 -1 0 0 0 0 0 %d %d 0 0 20 0 1 0 0 0 100\n", pid, pid, n/2, n-n/2)
+		}
+		writeFiles(t, root, files)
+	}
+	useConfig(t, "[processes]\nproc_root = \""+root+"\"\ncount = 10\n")
+
+	writeState(1200, map[int]int{1: 500, 2: 100, 3: 100, 4: 0})
+	c := &processesCollector{sampler: newDeltaSampler(0, readProcessSample, processCPUUsage)}
+	if _, err := c.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 400 jiffies over both cores pass: 200 ticks is one full core
+	if err := os.Remove(filepath.Join(root, "4", "stat")); err != nil {
+		t.Fatal(err)
+	}
+	writeState(1600, map[int]int{1: 400, 2: 300, 3: 200, 5: 50})
+	data, err := c.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[int]float64)
+	var order []int
+	for _, p := range data.(*types.ProcessesInfo).ByCPU {
+		got[p.PID] = p.CPUPercent
+		order = append(order, p.PID)
+	}
+	want := map[int]float64{
+		1: 0,   // ticks went backwards, e.g. a reused pid
+		2: 100, // 200 ticks
+		3: 50,  // 100 ticks
+		5: 0,   // started after the previous snapshot
+	}
+	for pid, percent := range want {
+		if math.Abs(got[pid]-percent) > 1e-9 {
+			t.Errorf("pid %d: CPU = %v%%, want %v%%", pid, got[pid], percent)
+		}
+	}
+	if _, ok := got[4]; ok {
+		t.Error("exited process 4 is listed")
+	}
+	if !slices.Equal(order[:2], []int{2, 3}) {
+		t.Errorf("order by CPU = %v, want 2 and 3 first", order)
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+	"golang.org/x/sys/unix"
+)
+
+// Proc connector values from linux/connector.h and linux/cn_proc.h
+const (
+	cnIdxProc         = 1
+	cnValProc         = 1
+	procCnMcastListen = 1
+
+	procEventFork = 0x00000001
+	procEventExec = 0x00000002
+	procEventComm = 0x00000200
+	procEventExit = 0x80000000
+
+	sizeofCnMsg       = 20 // id.idx, id.val, seq, ack, len, flags
+	sizeofProcEventHd = 16 // what, cpu, timestamp_ns
+)
+
+// procwatchCollector reports when processes matching the configured
+// patterns start or exit, e.g.
+//
+//	[procwatch]
+//	processes = ["obs", "steam", "ffmpeg*"]
+//
+// Patterns are globs matched against the process name and the base name of
+// its executable. Events come from the netlink proc connector; where it
+// cannot be opened, /proc is scanned every interval instead.
+type procwatchCollector struct{}
+
+func init() {
+	RegisterCollector(&procwatchCollector{})
+}
+
+// watchedProcess is a running process that matches at least one pattern
+type watchedProcess struct {
+	command  string
+	patterns []string
+}
+
+// Name returns the data type name
+func (p *procwatchCollector) Name() string {
+	return "procwatch"
+}
+
+// Interval returns the /proc scan interval used without the proc connector
+func (p *procwatchCollector) Interval() time.Duration {
+	return currentConfig().Section("procwatch").Duration("interval", 2*time.Second)
+}
+
+// Snapshot scans /proc for the watched processes
+func (p *procwatchCollector) Snapshot() (any, error) {
+	settings := currentConfig().Section("procwatch")
+	patterns := settings.Strings("processes", nil)
+	procs, err := scanWatchedProcesses(settings.String("proc_root", "/proc"), patterns)
+	if err != nil {
+		return nil, err
+	}
+	return procwatchInfo(patterns, procs, nil), nil
+}
+
+// Start emits the watched processes and then an update for every watched
+// process that starts or exits, until ctx is cancelled
+func (p *procwatchCollector) Start(ctx context.Context, emit EmitFunc) error {
+	settings := currentConfig().Section("procwatch")
+	patterns := settings.Strings("processes", nil)
+	procRoot := settings.String("proc_root", "/proc")
+
+	// Subscribe before the initial scan so no process is missed in between
+	conn, connErr := openProcConnector()
+	if connErr == nil {
+		defer conn.Close()
+		stop := context.AfterFunc(ctx, func() { conn.Close() })
+		defer stop()
+	}
+
+	procs, err := scanWatchedProcesses(procRoot, patterns)
+	if err != nil {
+		return err
+	}
+	emit(p.Name(), wrapData(p, procwatchInfo(patterns, procs, nil)))
+	if len(patterns) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	publish := func(event types.ProcessEvent) {
+		emit(p.Name(), wrapData(p, procwatchInfo(patterns, procs, &event)))
+	}
+	rescan := func() error {
+		current, err := scanWatchedProcesses(procRoot, patterns)
+		if err != nil {
+			return err
+		}
+		events := diffWatchedProcesses(procs, current)
+		procs = current
+		for _, event := range events {
+			publish(event)
+		}
+		return nil
+	}
+
+	if connErr != nil {
+		log.Printf("Proc connector unavailable, scanning %s instead: %v", procRoot, connErr)
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(p.Interval()):
+			}
+			if err := rescan(); err != nil {
+				return err
+			}
+		}
+	}
+
+	buf := make([]byte, os.Getpagesize())
+	for {
+		n, err := conn.Read(buf)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, unix.ENOBUFS) {
+			// The socket overflowed and events were lost
+			if err := rescan(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("read proc connector: %w", err)
+		}
+
+		for _, payload := range netlinkMessages(buf[:n]) {
+			if event, ok := handleProcEvent(procRoot, patterns, procs, payload); ok {
+				publish(event)
+			}
+		}
+	}
+}
+
+// openProcConnector subscribes to process events of the kernel
+func openProcConnector() (*os.File, error) {
+	conn, err := openNetlink(unix.NETLINK_CONNECTOR, cnIdxProc)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := make([]byte, unix.SizeofNlMsghdr+sizeofCnMsg+4)
+	binary.NativeEndian.PutUint32(msg[0:], uint32(len(msg)))
+	binary.NativeEndian.PutUint16(msg[4:], unix.NLMSG_DONE)
+	binary.NativeEndian.PutUint32(msg[12:], uint32(os.Getpid()))
+	cn := msg[unix.SizeofNlMsghdr:]
+	binary.NativeEndian.PutUint32(cn[0:], cnIdxProc)
+	binary.NativeEndian.PutUint32(cn[4:], cnValProc)
+	binary.NativeEndian.PutUint16(cn[16:], 4)
+	binary.NativeEndian.PutUint32(cn[sizeofCnMsg:], procCnMcastListen)
+	if _, err := conn.Write(msg); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("subscribe to proc connector: %w", err)
+	}
+	return conn, nil
+}
+
+// handleProcEvent updates procs from a proc connector message and returns
+// the resulting event if a watched process started or exited
+func handleProcEvent(procRoot string, patterns []string, procs map[int]watchedProcess, payload []byte) (types.ProcessEvent, bool) {
+	if len(payload) < sizeofCnMsg+sizeofProcEventHd+8 {
+		return types.ProcessEvent{}, false
+	}
+	event := payload[sizeofCnMsg:]
+	what := binary.NativeEndian.Uint32(event[0:])
+	data := event[sizeofProcEventHd:]
+
+	var pid, tgid uint32
+	switch what {
+	case procEventFork:
+		// parent_pid, parent_tgid, child_pid, child_tgid
+		if len(data) < 16 {
+			return types.ProcessEvent{}, false
+		}
+		pid, tgid = binary.NativeEndian.Uint32(data[8:]), binary.NativeEndian.Uint32(data[12:])
+	case procEventExec, procEventComm, procEventExit:
+		pid, tgid = binary.NativeEndian.Uint32(data[0:]), binary.NativeEndian.Uint32(data[4:])
+	default:
+		return types.ProcessEvent{}, false
+	}
+	// Threads are not processes
+	if pid != tgid {
+		return types.ProcessEvent{}, false
+	}
+
+	current, matches := watchedProcess{}, false
+	if what != procEventExit {
+		current, matches = matchWatchedProcess(procRoot, int(pid), patterns)
+	}
+	previous, tracked := procs[int(pid)]
+	switch {
+	case matches && !tracked:
+		procs[int(pid)] = current
+		return processEvent(types.ProcessStarted, int(pid), current), true
+	case matches:
+		procs[int(pid)] = current
+	case tracked:
+		// Exited, or replaced by another program
+		delete(procs, int(pid))
+		return processEvent(types.ProcessExited, int(pid), previous), true
+	}
+	return types.ProcessEvent{}, false
+}
+
+// scanWatchedProcesses finds the running processes matching patterns
+func scanWatchedProcesses(procRoot string, patterns []string) (map[int]watchedProcess, error) {
+	procs := make(map[int]watchedProcess)
+	if len(patterns) == 0 {
+		return procs, nil
+	}
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if proc, ok := matchWatchedProcess(procRoot, pid, patterns); ok {
+			procs[pid] = proc
+		}
+	}
+	return procs, nil
+}
+
+// matchWatchedProcess returns the patterns matching a process name or the
+// base name of its executable
+func matchWatchedProcess(procRoot string, pid int, patterns []string) (watchedProcess, bool) {
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+	comm := readSysfsString(filepath.Join(dir, "comm"))
+	if comm == "" {
+		return watchedProcess{}, false
+	}
+	// Names are cut to 15 characters, the executable has the full name
+	var exe string
+	if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil && len(cmdline) > 0 {
+		arg0, _, _ := strings.Cut(string(cmdline), "\x00")
+		exe = filepath.Base(arg0)
+	}
+
+	proc := watchedProcess{command: comm}
+	for _, pattern := range patterns {
+		if matchesAny(comm, []string{pattern}) || (exe != "" && matchesAny(exe, []string{pattern})) {
+			proc.patterns = append(proc.patterns, pattern)
+		}
+	}
+	return proc, len(proc.patterns) > 0
+}
+
+// diffWatchedProcesses returns the events turning old into current
+func diffWatchedProcesses(old, current map[int]watchedProcess) []types.ProcessEvent {
+	var events []types.ProcessEvent
+	for pid, proc := range old {
+		if _, ok := current[pid]; !ok {
+			events = append(events, processEvent(types.ProcessExited, pid, proc))
+		}
+	}
+	for pid, proc := range current {
+		if _, ok := old[pid]; !ok {
+			events = append(events, processEvent(types.ProcessStarted, pid, proc))
+		}
+	}
+	return events
+}
+
+func processEvent(action string, pid int, proc watchedProcess) types.ProcessEvent {
+	return types.ProcessEvent{Action: action, PID: pid, Command: proc.command, Patterns: proc.patterns}
+}
+
+// procwatchInfo builds the payload listing every pattern with its processes
+func procwatchInfo(patterns []string, procs map[int]watchedProcess, event *types.ProcessEvent) *types.ProcWatchInfo {
+	info := &types.ProcWatchInfo{Processes: []types.WatchedProcess{}, Event: event}
+	for _, pattern := range patterns {
+		watched := types.WatchedProcess{Pattern: pattern, PIDs: []int{}}
+		for pid, proc := range procs {
+			if slices.Contains(proc.patterns, pattern) {
+				watched.PIDs = append(watched.PIDs, pid)
+			}
+		}
+		slices.Sort(watched.PIDs)
+		watched.Running = len(watched.PIDs) > 0
+		info.Processes = append(info.Processes, watched)
+	}
+	return info
+}
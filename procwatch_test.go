@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/binary"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+// procConnectorPayload builds a proc connector message of the given kind
+// with the given event data
+func procConnectorPayload(what uint32, data ...uint32) []byte {
+	payload := make([]byte, sizeofCnMsg+sizeofProcEventHd)
+	binary.NativeEndian.PutUint32(payload[sizeofCnMsg:], what)
+	for _, v := range data {
+		payload = binary.NativeEndian.AppendUint32(payload, v)
+	}
+	// Exit events carry exit_code and exit_signal too
+	return binary.NativeEndian.AppendUint64(payload, 0)
+}
+
+func TestHandleProcEvent(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"100/comm":    "obs\n",
+		"100/cmdline": "/usr/bin/obs\x00--startreplaybuffer\x00",
+		"200/comm":    "bash\n",
+		"200/cmdline": "bash\x00",
+		// The name is cut to 15 characters, only the executable matches
+		"300/comm":    "steam-runtime-l\n",
+		"300/cmdline": "/opt/steam/steam-runtime-launcher\x00",
+	})
+	patterns := []string{"obs", "steam-runtime-launcher"}
+	procs := make(map[int]watchedProcess)
+
+	steps := []struct {
+		name    string
+		setup   map[string]string
+		payload []byte
+		want    string // "<action> <pid> <command>" or "" for no event
+	}{
+		{"exec of a watched process", nil, procConnectorPayload(procEventExec, 100, 100), "started 100 obs"},
+		{"second exec of a tracked process", nil, procConnectorPayload(procEventExec, 100, 100), ""},
+		{"fork of an unwatched process", nil, procConnectorPayload(procEventFork, 1, 1, 200, 200), ""},
+		{"thread of a watched process", nil, procConnectorPayload(procEventExec, 101, 100), ""},
+		{"match on the executable", nil, procConnectorPayload(procEventExec, 300, 300), "started 300 steam-runtime-l"},
+		{"renamed to another program", map[string]string{"100/comm": "sh\n", "100/cmdline": "sh\x00"}, procConnectorPayload(procEventComm, 100, 100), "exited 100 obs"},
+		{"exit of a watched process", nil, procConnectorPayload(procEventExit, 300, 300), "exited 300 steam-runtime-l"},
+		{"exit of an unwatched process", nil, procConnectorPayload(procEventExit, 200, 200), ""},
+		{"unknown event", nil, procConnectorPayload(0x4, 100, 100), ""},
+		{"short payload", nil, make([]byte, sizeofCnMsg+sizeofProcEventHd), ""},
+	}
+	for _, step := range steps {
+		writeFiles(t, root, step.setup)
+		event, ok := handleProcEvent(root, patterns, procs, step.payload)
+		var got string
+		if ok {
+			got = strings.Join([]string{event.Action, strconv.Itoa(event.PID), event.Command}, " ")
+		}
+		if got != step.want {
+			t.Errorf("%s: event = %q, want %q", step.name, got, step.want)
+		}
+	}
+	if len(procs) != 0 {
+		t.Errorf("tracked processes = %v, want none", procs)
+	}
+}
+
+func TestDiffWatchedProcesses(t *testing.T) {
+	obs := watchedProcess{command: "obs", patterns: []string{"obs"}}
+	steam := watchedProcess{command: "steam", patterns: []string{"steam*"}}
+	old := map[int]watchedProcess{10: obs, 20: steam}
+	current := map[int]watchedProcess{20: steam, 30: obs}
+
+	events := diffWatchedProcesses(old, current)
+	slices.SortFunc(events, func(a, b types.ProcessEvent) int { return a.PID - b.PID })
+	want := []types.ProcessEvent{
+		{Action: types.ProcessExited, PID: 10, Command: "obs", Patterns: []string{"obs"}},
+		{Action: types.ProcessStarted, PID: 30, Command: "obs", Patterns: []string{"obs"}},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v, want %+v", events, want)
+	}
+	for i := range want {
+		e, w := events[i], want[i]
+		if e.Action != w.Action || e.PID != w.PID || e.Command != w.Command || !slices.Equal(e.Patterns, w.Patterns) {
+			t.Errorf("event %d = %+v, want %+v", i, e, w)
+		}
+	}
+
+	if events := diffWatchedProcesses(current, current); len(events) != 0 {
+		t.Errorf("events without changes = %+v, want none", events)
+	}
+}
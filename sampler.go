@@ -0,0 +1,68 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// deltaSampler computes a result from the difference between two reads of
+// cumulative counters, such as /proc/stat or /proc/diskstats. sample takes a
+// new read, so every result covers the whole time since the previous one;
+// latest returns the last result without shortening that window.
+type deltaSampler[T, R any] struct {
+	window  time.Duration // delay between the two reads taken without a usable previous read
+	read    func() (T, error)
+	compute func(prev, cur T, elapsed time.Duration) R
+
+	mu       sync.Mutex
+	prev     T
+	prevTime time.Time // zero before the first read
+	last     R
+}
+
+// newDeltaSampler returns a sampler that reads the counters with read and
+// computes the result with compute
+func newDeltaSampler[T, R any](window time.Duration, read func() (T, error), compute func(prev, cur T, elapsed time.Duration) R) *deltaSampler[T, R] {
+	return &deltaSampler[T, R]{window: window, read: read, compute: compute}
+}
+
+// sample reads the counters and computes the result since the previous
+// read. Without a previous read, or with one older than maxAge, it reads
+// twice, window apart, to get a meaningful delta.
+func (s *deltaSampler[T, R]) sample(maxAge time.Duration) (R, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sampleLocked(maxAge)
+}
+
+// latest returns the last result if it is younger than maxAge and takes a
+// new sample otherwise
+func (s *deltaSampler[T, R]) latest(maxAge time.Duration) (R, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.prevTime.IsZero() && time.Since(s.prevTime) <= maxAge {
+		return s.last, nil
+	}
+	return s.sampleLocked(maxAge)
+}
+
+func (s *deltaSampler[T, R]) sampleLocked(maxAge time.Duration) (R, error) {
+	var zero R
+	if s.prevTime.IsZero() || time.Since(s.prevTime) > maxAge {
+		prev, err := s.read()
+		if err != nil {
+			return zero, err
+		}
+		s.prev, s.prevTime = prev, time.Now()
+		time.Sleep(s.window)
+	}
+
+	cur, err := s.read()
+	if err != nil {
+		return zero, err
+	}
+	now := time.Now()
+	s.last = s.compute(s.prev, cur, now.Sub(s.prevTime))
+	s.prev, s.prevTime = cur, now
+	return s.last, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeltaSampler(t *testing.T) {
+	var counter int
+	var readErr error
+	reads := 0
+	s := newDeltaSampler(time.Millisecond, func() (int, error) {
+		reads++
+		return counter, readErr
+	}, func(prev, cur int, _ time.Duration) int {
+		return cur - prev
+	})
+
+	// The first sample reads twice
+	counter = 5
+	if got, err := s.sample(time.Hour); err != nil || got != 0 || reads != 2 {
+		t.Fatalf("first sample = %d, %v after %d reads, want 0 after 2 reads", got, err, reads)
+	}
+
+	counter = 12
+	if got, _ := s.sample(time.Hour); got != 7 || reads != 3 {
+		t.Errorf("second sample = %d after %d reads, want 7 after 3 reads", got, reads)
+	}
+
+	// latest repeats the last result without reading
+	counter = 20
+	if got, _ := s.latest(time.Hour); got != 7 || reads != 3 {
+		t.Errorf("latest = %d after %d reads, want 7 after 3 reads", got, reads)
+	}
+	if got, _ := s.sample(time.Hour); got != 8 {
+		t.Errorf("sample after latest = %d, want 8 since the previous sample", got)
+	}
+
+	// A stale previous read is replaced by a fresh window
+	time.Sleep(2 * time.Millisecond)
+	counter = 30
+	if got, _ := s.latest(time.Millisecond); got != 0 || reads != 6 {
+		t.Errorf("latest after max age = %d after %d reads, want 0 after 6 reads", got, reads)
+	}
+
+	readErr = errors.New("read failed")
+	if _, err := s.sample(time.Hour); err == nil {
+		t.Error("expected the read error")
+	}
+}
@@ -3,13 +3,18 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net"
 	"os"
+	"os/user"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 
-	"github.com/GcZuRi1886/system-info-provider/types"
+	"golang.org/x/sys/unix"
 )
 
 // Groups of clients subscribed to each info type
@@ -22,19 +27,19 @@ var subscribers = struct {
 func broadcast(infoType string, data any) {
 	msg, err := marshalData(data)
 	if err != nil {
-		fmt.Printf("Error marshaling data for broadcast: %v\n", err)
+		reportCollectorError(infoType, fmt.Errorf("error marshaling data for broadcast: %w", err))
 		return
 	}
 
+	// Copy the subscribers so a failed write can unsubscribe the client
 	subscribers.RLock()
-	defer subscribers.RUnlock()
-
-	conns, ok := subscribers.m[strings.ToUpper(infoType)]
-	if !ok {
-		return
+	var conns []net.Conn
+	for c := range subscribers.m[strings.ToUpper(infoType)] {
+		conns = append(conns, c)
 	}
+	subscribers.RUnlock()
 
-	for c := range conns {
+	for _, c := range conns {
 		writeToConn(c, msg)
 	}
 }
@@ -50,33 +55,46 @@ func marshalData(data any) (string, error) {
 func writeToConn(c net.Conn, msg string) {
 	_, err := c.Write([]byte(msg))
 	if err != nil {
-		fmt.Errorf("Write error: %v", err)
+		log.Printf("Write error: %v", err)
 		removeClientFromAllTypes(c)
 		c.Close()
 	}
 }
 
-// Subscribe a client to a data type
-func subscribe(c net.Conn, infoType string) {
+// Subscribe a client to a data type. Reports whether the collector of the
+// type was started by this subscription.
+func subscribe(c net.Conn, collector Collector) bool {
 	subscribers.Lock()
 	defer subscribers.Unlock()
 
+	infoType := strings.ToUpper(collector.Name())
 	if subscribers.m[infoType] == nil {
 		subscribers.m[infoType] = make(map[net.Conn]bool)
 	}
-	
+
 	fmt.Println("Subscribing client to", infoType)
 
 	subscribers.m[infoType][c] = true
+	if len(subscribers.m[infoType]) == 1 {
+		return acquireCollector(collector)
+	}
+	return false
 }
 
-// Remove client from all subscription lists
+// Remove client from all subscription lists and release collectors that
+// have no subscribers left
 func removeClientFromAllTypes(c net.Conn) {
 	subscribers.Lock()
 	defer subscribers.Unlock()
 
-	for _, conns := range subscribers.m {
+	for infoType, conns := range subscribers.m {
+		if !conns[c] {
+			continue
+		}
 		delete(conns, c)
+		if len(conns) == 0 {
+			releaseCollector(strings.ToLower(infoType))
+		}
 	}
 }
 
@@ -105,10 +123,37 @@ func handleClient(conn net.Conn) {
 		parts := strings.SplitN(cmd, " ", 2)
 
 		if len(parts) == 2 && strings.ToUpper(parts[0]) == "SUB" {
-			infoType := strings.ToUpper(parts[1])
-			subscribe(conn, infoType)
-			conn.Write([]byte("OK subscribed to " + infoType + "\n"))
-			getInitialStates(conn, infoType)
+			collector, ok := lookupCollector(strings.TrimSpace(parts[1]))
+			if !ok {
+				conn.Write([]byte("ERROR unknown type " + strings.ToUpper(parts[1]) + "\n"))
+				continue
+			}
+			if _, ok := collector.(OnDemand); ok {
+				conn.Write([]byte("ERROR " + strings.ToUpper(collector.Name()) + " is only available with GET\n"))
+				continue
+			}
+			if !currentConfig().Section(collector.Name()).Enabled() {
+				conn.Write([]byte("ERROR " + strings.ToUpper(collector.Name()) + " is disabled\n"))
+				continue
+			}
+			conn.Write([]byte("OK subscribed to " + strings.ToUpper(collector.Name()) + "\n"))
+			started := subscribe(conn, collector)
+			// A freshly started collector emits its state right away
+			if !started {
+				getInitialState(conn, collector)
+			}
+			continue
+		}
+
+		// Example: "GET PROCESSES"
+		if len(parts) == 2 && strings.ToUpper(parts[0]) == "GET" {
+			conn.Write([]byte(handleGet(strings.TrimSpace(parts[1]))))
+			continue
+		}
+
+		// Example: "SET FANS hwmon5/pwm1 auto"
+		if len(parts) == 2 && strings.ToUpper(parts[0]) == "SET" {
+			conn.Write([]byte(handleSet(conn, strings.Fields(parts[1])) + "\n"))
 			continue
 		}
 
@@ -116,33 +161,112 @@ func handleClient(conn net.Conn) {
 	}
 }
 
-func getInitialStates(conn net.Conn, infoType string) {
+// handleSet runs a SET command from conn on the collector of the given type
+// and returns the reply line
+func handleSet(conn net.Conn, args []string) string {
+	if len(args) == 0 {
+		return "ERROR usage: SET <TYPE> <args...>"
+	}
+	collector, ok := lookupCollector(args[0])
+	if !ok {
+		return "ERROR unknown type " + strings.ToUpper(args[0])
+	}
+	setter, ok := collector.(Setter)
+	if !ok {
+		return "ERROR " + strings.ToUpper(collector.Name()) + " does not support SET"
+	}
+	if err := authorizeSet(conn, collector); err != nil {
+		return "ERROR " + err.Error()
+	}
+	if err := setter.Set(args[1:]); err != nil {
+		return "ERROR " + err.Error()
+	}
+	return "OK"
+}
 
-	switch infoType {
-	case "BLUETOOTH":
-		// Initial emit of current bluetooth state
-		var bluetoothDataWrapper = initBluetoothDataWrapper()
-		loadInitialBluezState(bluetoothDataWrapper.Data.(*types.BluetoothInfo))
-		msg, err := marshalData(bluetoothDataWrapper)
+// handleGet returns the current state of the collector of the given type
+// as a message, or an error reply line
+func handleGet(name string) string {
+	collector, ok := lookupCollector(name)
+	if !ok {
+		return "ERROR unknown type " + strings.ToUpper(name) + "\n"
+	}
+	if _, ok := collector.(SubscribeOnly); ok {
+		return "ERROR " + strings.ToUpper(collector.Name()) + " is only available with SUB\n"
+	}
+	if !currentConfig().Section(collector.Name()).Enabled() {
+		return "ERROR " + strings.ToUpper(collector.Name()) + " is disabled\n"
+	}
+	data, err := snapshotCollector(collector)
+	if data == nil {
 		if err == nil {
-			writeToConn(conn, msg)
-		}
-	case "WORKSPACE", "HYPRLAND":
-		// Initial emit of current workspace state (compositor-agnostic)
-		provider := NewWorkspaceProvider()
-		if provider != nil {
-			state, err := provider.GetWorkspaceState()
-			if err == nil {
-				wrapper := types.Wrapper{
-					Type: "workspace",
-					Data: state,
-				}
-				msg, err := marshalData(wrapper)
-				if err == nil {
-					writeToConn(conn, msg)
-				}
-			}
+			err = errors.New("no data available")
 		}
+		return "ERROR " + err.Error() + "\n"
+	}
+	if err != nil {
+		reportCollectorError(collector.Name(), err)
+	}
+	msg, err := marshalData(wrapData(collector, data))
+	if err != nil {
+		return "ERROR " + err.Error() + "\n"
+	}
+	return msg
+}
+
+// authorizeSet checks that the client on conn may change the collector.
+// Root and the user running the daemon always may, other users only if
+// they are listed in allowed_users of the collector's section.
+func authorizeSet(conn net.Conn, collector Collector) error {
+	uid, err := peerUID(conn)
+	if err != nil {
+		return fmt.Errorf("cannot identify client: %w", err)
+	}
+	if uid == 0 || uid == uint32(os.Getuid()) {
+		return nil
+	}
+	if u, err := user.LookupId(strconv.FormatUint(uint64(uid), 10)); err == nil {
+		allowed := currentConfig().Section(collector.Name()).Strings("allowed_users", nil)
+		if slices.Contains(allowed, u.Username) {
+			return nil
+		}
+	}
+	return fmt.Errorf("user %d is not allowed to change %s", uid, strings.ToUpper(collector.Name()))
+}
+
+// peerUID returns the uid of the process connected to a Unix socket
+func peerUID(conn net.Conn) (uint32, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return 0, errors.New("not a unix socket")
+	}
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var cred *unix.Ucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err = errors.Join(err, credErr); err != nil {
+		return 0, err
+	}
+	return cred.Uid, nil
+}
+
+// getInitialState sends the current state of a collector to a new subscriber
+func getInitialState(conn net.Conn, collector Collector) {
+	data, err := snapshotCollector(collector)
+	if data == nil {
+		if err != nil {
+			log.Printf("Error getting initial %s state: %v", collector.Name(), err)
+		}
+		return
+	}
+	msg, err := marshalData(wrapData(collector, data))
+	if err == nil {
+		writeToConn(conn, msg)
 	}
 }
 
@@ -160,6 +284,10 @@ func connectToSocket(socketPath string) (net.Listener, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to listen on unix socket: %w", err)
 	}
+	if err := setSocketPermissions(socketPath, currentConfig().Section("general")); err != nil {
+		listener.Close()
+		return nil, err
+	}
 
 	fmt.Printf("Server listening on %s\n", socketPath)
 
@@ -168,7 +296,7 @@ func connectToSocket(socketPath string) (net.Listener, error) {
 		for {
 			conn, err := listener.Accept()
 			if err != nil {
-				fmt.Printf("Accept error: %v\n", err)
+				log.Printf("Accept error: %v", err)
 				continue
 			}
 
@@ -180,6 +308,47 @@ func connectToSocket(socketPath string) (net.Listener, error) {
 	return listener, nil
 }
 
+// setSocketPermissions applies socket_group and socket_mode of [general] to
+// the socket file, so other users can be given access through a group.
+// Without them the socket keeps the owner and umask of the daemon.
+func setSocketPermissions(path string, settings Section) error {
+	if group := settings.String("socket_group", ""); group != "" {
+		g, err := user.LookupGroup(group)
+		if err != nil {
+			g, err = user.LookupGroupId(group)
+		}
+		if err != nil {
+			return fmt.Errorf("socket_group: unknown group %q", group)
+		}
+		gid, _ := strconv.Atoi(g.Gid)
+		if err := os.Chown(path, -1, gid); err != nil {
+			return fmt.Errorf("set socket group: %w", err)
+		}
+	}
+
+	var mode uint64
+	switch v := settings["socket_mode"].(type) {
+	case nil:
+		return nil
+	case string:
+		var err error
+		if mode, err = strconv.ParseUint(v, 8, 32); err != nil {
+			return fmt.Errorf("socket_mode: invalid octal mode %q", v)
+		}
+	case int64:
+		mode = uint64(v)
+	default:
+		return errors.New(`socket_mode: expected an octal string like "0660"`)
+	}
+	if mode > 0o777 {
+		return fmt.Errorf("socket_mode: mode %o out of range", mode)
+	}
+	if err := os.Chmod(path, os.FileMode(mode)); err != nil {
+		return fmt.Errorf("set socket mode: %w", err)
+	}
+	return nil
+}
+
 // ---- Your system info loops ----
 func startSystemInfoLoops() {
 	// Example loop: send CPU info
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestSetSocketPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sock")
+	writeFiles(t, filepath.Dir(path), map[string]string{"test.sock": ""})
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		document string
+		mode     os.FileMode
+	}{
+		{"unset", "[general]\n", 0o600},
+		{"octal string", "[general]\nsocket_mode = \"0660\"\n", 0o660},
+		{"string without zero", "[general]\nsocket_mode = \"640\"\n", 0o640},
+		{"octal integer", "[general]\nsocket_mode = 0o666\n", 0o666},
+		{"own group by id", "[general]\nsocket_group = \"" + strconv.Itoa(os.Getgid()) + "\"\nsocket_mode = \"0660\"\n", 0o660},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := parseTOML(tt.document)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := &Config{values: values}
+			if err := setSocketPermissions(path, cfg.Section("general")); err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.Mode().Perm(); got != tt.mode {
+				t.Errorf("mode = %o, want %o", got, tt.mode)
+			}
+		})
+	}
+
+	invalid := []Section{
+		{"socket_mode": "rw-rw----"},
+		{"socket_mode": "0999"},
+		{"socket_mode": int64(0o1777)},
+		{"socket_mode": int64(-1)},
+		{"socket_mode": true},
+		{"socket_group": "no-such-group-for-this-test"},
+	}
+	for _, settings := range invalid {
+		if err := setSocketPermissions(path, settings); err == nil {
+			t.Errorf("setSocketPermissions(%v) succeeded, want an error", settings)
+		}
+	}
+}
+
+func TestDisabledCollectorRejected(t *testing.T) {
+	useConfig(t, "[cpu]\nenabled = false\n")
+
+	if got, want := handleGet("cpu"), "ERROR CPU is disabled\n"; got != want {
+		t.Errorf("GET CPU = %q, want %q", got, want)
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+	go handleClient(server)
+
+	reader := bufio.NewReader(client)
+	if _, err := reader.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Write([]byte("SUB CPU\n")); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ERROR CPU is disabled\n"; reply != want {
+		t.Errorf("SUB CPU = %q, want %q", reply, want)
+	}
+
+	subscribers.Lock()
+	subscribed := subscribers.m["CPU"][server]
+	subscribers.Unlock()
+	if subscribed {
+		t.Error("client was subscribed to a disabled collector")
+	}
+}
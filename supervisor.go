@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+// Restart backoff of failed collectors
+const (
+	supervisorMinBackoff = time.Second
+	supervisorMaxBackoff = time.Minute
+)
+
+// superviseCollector runs c until ctx is cancelled. Whenever Start returns
+// or panics, the collector is restarted with exponential backoff. Every
+// state change is published on the status type, and every emitted update
+// counts as a success of the collector.
+func superviseCollector(ctx context.Context, c Collector, emit EmitFunc) {
+	name := c.Name()
+	backoff := supervisorMinBackoff
+	restarts := 0
+
+	emitAndReport := func(dataType string, data any) {
+		reportCollectorSuccess(name)
+		emit(dataType, data)
+	}
+
+	for {
+		setCollectorStatus(name, types.CollectorRunning, restarts, nil)
+		started := time.Now()
+		err := startCollector(ctx, c, emitAndReport)
+		if ctx.Err() != nil {
+			setCollectorStatus(name, types.CollectorStopped, restarts, nil)
+			return
+		}
+		if err == nil {
+			err = errors.New("collector returned")
+		}
+
+		// A collector that ran for a while is restarted quickly again
+		if time.Since(started) > supervisorMaxBackoff {
+			backoff = supervisorMinBackoff
+		}
+		log.Printf("Collector %s stopped: %v; restarting in %s", name, err, backoff)
+		setCollectorStatus(name, types.CollectorRestarting, restarts, err)
+
+		select {
+		case <-ctx.Done():
+			setCollectorStatus(name, types.CollectorStopped, restarts, err)
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, supervisorMaxBackoff)
+		restarts++
+	}
+}
+
+// startCollector runs c.Start and turns a panic into an error
+func startCollector(ctx context.Context, c Collector, emit EmitFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Collector %s panicked: %v\n%s", c.Name(), r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return c.Start(ctx, emit)
+}
+
+// snapshotCollector calls c.Snapshot and turns a panic into an error
+func snapshotCollector(c Collector) (data any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Collector %s panicked: %v\n%s", c.Name(), r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return c.Snapshot()
+}
+
+// Status of every collector that has been started, keyed by name
+var collectorStatus = struct {
+	sync.Mutex
+	m       map[string]*types.CollectorStatus
+	changed chan struct{} // signalled when a state or degraded flag changes
+}{m: make(map[string]*types.CollectorStatus), changed: make(chan struct{}, 1)}
+
+// updateCollectorStatus applies fn to the status of a collector and notifies
+// the status collector if the state or the degraded flag changed.
+// Successful updates alone are not announced to keep the status type quiet.
+func updateCollectorStatus(name string, fn func(status *types.CollectorStatus)) {
+	collectorStatus.Lock()
+	status, ok := collectorStatus.m[name]
+	if !ok {
+		status = &types.CollectorStatus{State: types.CollectorStopped, Since: time.Now()}
+		collectorStatus.m[name] = status
+	}
+	oldState, oldDegraded := status.State, status.Degraded
+	fn(status)
+	status.Degraded = status.State == types.CollectorRestarting ||
+		(status.LastErrorTime != nil && (status.LastSuccess == nil || status.LastErrorTime.After(*status.LastSuccess)))
+	changed := status.State != oldState || status.Degraded != oldDegraded
+	collectorStatus.Unlock()
+
+	if changed {
+		select {
+		case collectorStatus.changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// setCollectorStatus records a state change of a collector
+func setCollectorStatus(name, state string, restarts int, err error) {
+	updateCollectorStatus(name, func(status *types.CollectorStatus) {
+		status.State = state
+		status.Since = time.Now()
+		status.Restarts = restarts
+		if err != nil {
+			now := time.Now()
+			status.LastError = err.Error()
+			status.LastErrorTime = &now
+		}
+	})
+}
+
+// reportCollectorSuccess records a successful update of a collector
+func reportCollectorSuccess(name string) {
+	updateCollectorStatus(name, func(status *types.CollectorStatus) {
+		now := time.Now()
+		status.LastSuccess = &now
+	})
+}
+
+// reportCollectorError records an error of a collector that keeps running.
+// The collector is reported as degraded until its next successful update.
+func reportCollectorError(name string, err error) {
+	log.Printf("Collector %s: %v", name, err)
+	updateCollectorStatus(name, func(status *types.CollectorStatus) {
+		now := time.Now()
+		status.LastError = err.Error()
+		status.LastErrorTime = &now
+	})
+}
+
+// statusCollector publishes the health of all collectors
+type statusCollector struct{}
+
+func init() {
+	RegisterCollector(&statusCollector{})
+}
+
+// Name returns the data type name
+func (s *statusCollector) Name() string {
+	return "status"
+}
+
+// Interval returns zero, status updates are event driven
+func (s *statusCollector) Interval() time.Duration {
+	return 0
+}
+
+// Snapshot returns the status of every collector, keyed by name
+func (s *statusCollector) Snapshot() (any, error) {
+	collectorStatus.Lock()
+	defer collectorStatus.Unlock()
+
+	snapshot := make(map[string]types.CollectorStatus, len(collectorStatus.m))
+	for name, status := range collectorStatus.m {
+		snapshot[name] = *status
+	}
+	return snapshot, nil
+}
+
+// Start emits the status whenever a collector changes state
+func (s *statusCollector) Start(ctx context.Context, emit EmitFunc) error {
+	for {
+		data, _ := s.Snapshot()
+		emit(s.Name(), wrapData(s, data))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-collectorStatus.changed:
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// readSysfsString returns the trimmed content of a sysfs attribute, or ""
+func readSysfsString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// readSysfsInt returns the integer value of a sysfs attribute, or 0
+func readSysfsInt(path string) int {
+	value, _ := strconv.Atoi(readSysfsString(path))
+	return value
+}
+
+// readSysfsIntOK returns the integer value of a sysfs attribute and whether
+// it could be read
+func readSysfsIntOK(path string) (int, bool) {
+	value, err := strconv.Atoi(readSysfsString(path))
+	return value, err == nil
+}
+
+// isWritable reports whether the daemon may write path
+func isWritable(path string) bool {
+	return unix.Access(path, unix.W_OK) == nil
+}
+
+// writeSysfsInt writes an integer to a sysfs attribute
+func writeSysfsInt(path string, value int) error {
+	return os.WriteFile(path, []byte(strconv.Itoa(value)), 0)
+}
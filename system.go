@@ -1,108 +1,114 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
-	"os"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/GcZuRi1886/system-info-provider/types"
-	"github.com/shirou/gopsutil/v4/cpu"
-	"github.com/shirou/gopsutil/v4/mem"
 )
 
-var systemInfo types.CurrentStateData
-var systemInfoWrapper types.Wrapper
+// systemCollector periodically reports time, CPU, memory, battery and network
+type systemCollector struct {
+	cpu *cpuSampler
+}
+
+func init() {
+	RegisterCollector(&systemCollector{cpu: newCPUSampler()})
+}
 
+// Name returns the data type name
+func (s *systemCollector) Name() string {
+	return "system"
+}
+
+// Interval returns the configured polling interval
+func (s *systemCollector) Interval() time.Duration {
+	return currentConfig().Section("system").Duration("interval", 3*time.Second)
+}
+
+// Start emits system info every interval, and right away when a charger or
+// battery changes, until ctx is cancelled. Only the periodic updates take a
+// new CPU sample.
+func (s *systemCollector) Start(ctx context.Context, emit EmitFunc) error {
+	pollPowerSupplies(ctx, s.Interval,
+		func() { publishSample(s, emit) },
+		func() { publishSnapshot(s, emit) })
+	return nil
+}
+
+// Snapshot returns system info with the CPU usage of the last sample
+func (s *systemCollector) Snapshot() (any, error) {
+	return s.info(s.cpu.latest)
+}
+
+// Sample returns system info with the CPU usage since the previous sample
+func (s *systemCollector) Sample() (any, error) {
+	return s.info(s.cpu.sample)
+}
 
 // ----- periodic system info -----
-func sysInfoLoop(emit func(dataType string, data any)) {
-	systemInfoWrapper.Type = "system"
-	systemInfoWrapper.Data = &systemInfo
-	for {
-		// Time
-		now := time.Now().Format("Mon 01 Jan 15:04:05")
-
-		// CPU usage 
-		cpuPercent, _ := cpu.Percent(0, true)
-		cpuUsage := cpuPercent // per core
-		avgPercent, _ := cpu.Percent(0, false)
-
-		// Memory usage
-		vm, _ := mem.VirtualMemory()
-		totalMem := vm.Total
-		usedMem := vm.Used
-
-		// Update battery info
-		batteryInfo := getBatteryInfo()
-		
-		// Audio info
-		//audioInfo, err := GetAudioInfo()
-		//if err != nil {
-		//	fmt.Println("Error getting audio info:", err)
-		//}
-
-		networkinfo, err := getNetworkInfo()
-		if err != nil {
-			fmt.Println("Error getting network info:", err)
-		}
+func (s *systemCollector) info(cpuSample func(maxAge time.Duration) (*types.CPUInfo, error)) (any, error) {
+	settings := currentConfig().Section("system")
 
-		systemInfo.Time = now
-		systemInfo.CPUPerCore = cpuUsage
-		systemInfo.CPUAverage = avgPercent[0]
-		systemInfo.MemoryUsed = int(usedMem)
-		systemInfo.MemoryTotal = int(totalMem)
-		systemInfo.Battery = *batteryInfo
-		systemInfo.Network = *networkinfo
-		emit(systemInfoWrapper.Type, systemInfoWrapper)
+	// Time
+	now := time.Now().Format(settings.String("time_format", "Mon 01 Jan 15:04:05"))
 
-		time.Sleep(3 * time.Second)
+	// CPU usage over the whole interval since the previous snapshot
+	var cpuUsage []float64 // per core
+	var avgPercent float64
+	cpuInfo, cpuErr := cpuSample(2 * s.Interval())
+	if cpuErr == nil {
+		for _, core := range cpuInfo.PerCore {
+			cpuUsage = append(cpuUsage, core.Usage)
+		}
+		avgPercent = cpuInfo.Total.Usage
 	}
-}
+	// Frequencies, governors and core types to label CPUPerCore
+	cpuCores, cpuCoresErr := readCPUCores(currentConfig().Section("cpu").String("sys_root", "/sys"))
 
-// ----- get battery info -----
-func getBatteryInfo() *types.BatteryInfo {
-	batteryBase := "/sys/class/power_supply/BAT0/"
-	
-	batteryInfo, err := os.Open(batteryBase + "uevent")
-	if err != nil {
-		return &types.BatteryInfo{}
+	// Memory usage. memory_used keeps its old meaning of memory that is
+	// neither free nor buffers or cache.
+	memoryInfo, memoryErr := readMemoryInfo(settings.String("sys_root", "/sys"))
+	if memoryInfo == nil {
+		memoryInfo = &types.MemoryInfo{}
 	}
-	defer batteryInfo.Close()
-
-	batteryData := make(map[string]string)
-	scanner := bufio.NewScanner(batteryInfo)
-	
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			batteryData[parts[0]] = parts[1]
-		}
+	totalMem := memoryInfo.Total
+	var usedMem uint64
+	if unused := memoryInfo.Free + memoryInfo.Buffers + memoryInfo.Cached; unused <= totalMem {
+		usedMem = totalMem - unused
 	}
-	
-
-	if len(batteryData) > 0 {
-		chargeRate, _ := strconv.ParseFloat(batteryData["POWER_SUPPLY_POWER_NOW"], 64)
-		currentCapacity, _ := strconv.ParseFloat(batteryData["POWER_SUPPLY_ENERGY_NOW"], 64)
-		fullCapacity, _ := strconv.ParseFloat(batteryData["POWER_SUPPLY_ENERGY_FULL"], 64)
-		currentPercentage, _ := strconv.Atoi(batteryData["POWER_SUPPLY_CAPACITY"])
-		status := batteryData["POWER_SUPPLY_STATUS"]
-		if chargeRate == 0 {
-			chargeRate = 1 // to avoid division by zero
-		}
-		battery := &types.BatteryInfo{
-			Percentage:  currentPercentage,
-			State:       status,
-			TimeToEmpty: currentCapacity / chargeRate * 60, // in minutes
-			TimeToFull:  (fullCapacity - currentCapacity) / chargeRate * 60, // in minutes
-		}
-		return battery
+
+	// Update battery info, all batteries combined
+	power, batteryErr := readPowerSupplies(batterySysRoot())
+	if batteryErr != nil {
+		power = &types.PowerInfo{}
+	}
+
+	// Audio info
+	//audioInfo, err := GetAudioInfo()
+	//if err != nil {
+	//	fmt.Println("Error getting audio info:", err)
+	//}
+
+	networkinfo, networkErr := getNetworkInfo()
+	if networkErr != nil {
+		networkErr = fmt.Errorf("error getting network info: %w", networkErr)
+		networkinfo = &types.NetworkInfo{}
+	}
+
+	systemInfo := &types.CurrentStateData{
+		Time:        now,
+		CPUPerCore:  cpuUsage,
+		CPUAverage:  avgPercent,
+		CPUCores:    cpuCores,
+		MemoryUsed:  int(usedMem),
+		MemoryTotal: int(totalMem),
+		Memory:      *memoryInfo,
+		Battery:     power.Total,
+		Network:     *networkinfo,
 	}
-	return &types.BatteryInfo{}
+	// Partial data is still published, the collector is reported as degraded
+	return systemInfo, errors.Join(cpuErr, cpuCoresErr, memoryErr, batteryErr, networkErr)
 }
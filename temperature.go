@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+// Labels of the sensors used as primary sensor when none is configured,
+// in order of preference
+var defaultPrimarySensors = []string{
+	"coretemp/Package id 0", // Intel
+	"k10temp/Tctl",          // AMD
+	"zenpower/Tdie",         // AMD with zenpower
+	"cpu_thermal/temp1",     // Raspberry Pi and other ARM boards
+	"x86_pkg_temp",          // Intel thermal zone
+}
+
+// temperatureCollector reports hwmon and thermal zone temperatures
+type temperatureCollector struct{}
+
+func init() {
+	RegisterCollector(&temperatureCollector{})
+}
+
+// Name returns the data type name
+func (t *temperatureCollector) Name() string {
+	return "temperature"
+}
+
+// Interval returns the configured polling interval
+func (t *temperatureCollector) Interval() time.Duration {
+	return currentConfig().Section("temperature").Duration("interval", 3*time.Second)
+}
+
+// Snapshot reads all temperature sensors
+func (t *temperatureCollector) Snapshot() (any, error) {
+	settings := currentConfig().Section("temperature")
+	sysRoot := settings.String("sys_root", "/sys")
+
+	hwmonSensors, hwmonErr := readHwmonTemperatures(sysRoot)
+	zoneSensors, zoneErr := readThermalZones(sysRoot)
+	if hwmonErr != nil && zoneErr != nil {
+		return nil, errors.Join(hwmonErr, zoneErr)
+	}
+
+	info := &types.TemperatureInfo{Sensors: append(hwmonSensors, zoneSensors...)}
+	info.Primary = findPrimarySensor(info.Sensors, settings.String("primary", ""))
+	return info, nil
+}
+
+// Start emits the temperatures every interval until ctx is cancelled
+func (t *temperatureCollector) Start(ctx context.Context, emit EmitFunc) error {
+	return pollCollector(ctx, t, emit)
+}
+
+// readHwmonTemperatures reads temp*_input of every hwmon device
+func readHwmonTemperatures(sysRoot string) ([]types.TemperatureSensor, error) {
+	devices, err := listHwmon(sysRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var sensors []types.TemperatureSensor
+	for _, dev := range devices {
+		for _, channel := range dev.channels("temp", "input") {
+			milli, ok := dev.attrInt(channel + "_input")
+			if !ok {
+				continue
+			}
+			label := dev.attr(channel + "_label")
+			if label == "" {
+				label = channel
+			}
+			sensors = append(sensors, types.TemperatureSensor{
+				ID:       dev.id + "/" + channel,
+				Chip:     dev.name,
+				Label:    label,
+				Celsius:  milliToUnit(milli),
+				Max:      optionalMilli(dev.attrInt(channel + "_max")),
+				Critical: optionalMilli(dev.attrInt(channel + "_crit")),
+			})
+		}
+	}
+	return sensors, nil
+}
+
+// readThermalZones reads <sysRoot>/class/thermal/thermal_zone*
+func readThermalZones(sysRoot string) ([]types.TemperatureSensor, error) {
+	zones, err := filepath.Glob(filepath.Join(sysRoot, "class", "thermal", "thermal_zone*"))
+	if err != nil {
+		return nil, err
+	}
+	if len(zones) == 0 {
+		return nil, errors.New("no thermal zones found")
+	}
+	slices.SortFunc(zones, naturalCompare)
+
+	var sensors []types.TemperatureSensor
+	for _, dir := range zones {
+		milli, ok := readSysfsIntOK(filepath.Join(dir, "temp"))
+		if !ok {
+			continue
+		}
+		zoneType := readSysfsString(filepath.Join(dir, "type"))
+		sensor := types.TemperatureSensor{
+			ID:      filepath.Base(dir),
+			Chip:    zoneType,
+			Label:   zoneType,
+			Celsius: milliToUnit(milli),
+		}
+
+		// Trip points give the thresholds: "hot" as max, "critical" as critical
+		trips, _ := filepath.Glob(filepath.Join(dir, "trip_point_*_type"))
+		for _, trip := range trips {
+			tempPath := strings.TrimSuffix(trip, "_type") + "_temp"
+			switch readSysfsString(trip) {
+			case "hot":
+				sensor.Max = optionalMilli(readSysfsIntOK(tempPath))
+			case "critical":
+				sensor.Critical = optionalMilli(readSysfsIntOK(tempPath))
+			}
+		}
+		sensors = append(sensors, sensor)
+	}
+	return sensors, nil
+}
+
+// findPrimarySensor returns the sensor selected by the "primary" setting,
+// which may be a sensor ID, "<chip>/<label>" or a label. Without a setting
+// the first known CPU sensor is used, falling back to the first sensor.
+func findPrimarySensor(sensors []types.TemperatureSensor, primary string) *types.TemperatureSensor {
+	candidates := defaultPrimarySensors
+	if primary != "" {
+		candidates = []string{primary}
+	}
+
+	for _, name := range candidates {
+		for i, sensor := range sensors {
+			if name == sensor.ID || name == sensor.Chip+"/"+sensor.Label || name == sensor.Label {
+				return &sensors[i]
+			}
+		}
+	}
+	if primary == "" && len(sensors) > 0 {
+		return &sensors[0]
+	}
+	return nil
+}
+
+// milliToUnit converts millidegrees (or millivolts, milliwatts, ...) to units
+func milliToUnit(milli int) float64 {
+	return float64(milli) / 1000
+}
+
+// optionalMilli converts an optional millidegree value
+func optionalMilli(milli int, ok bool) *float64 {
+	if !ok || milli <= 0 {
+		return nil
+	}
+	value := milliToUnit(milli)
+	return &value
+}
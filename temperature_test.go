@@ -0,0 +1,165 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+)
+
+// temperatureTree is a fake sysfs with an Intel CPU, an NVMe drive and two
+// thermal zones
+var temperatureTree = map[string]string{
+	"class/hwmon/hwmon0/name":        "nvme\n",
+	"class/hwmon/hwmon0/temp1_input": "38850\n",
+	"class/hwmon/hwmon0/temp1_label": "Composite\n",
+	"class/hwmon/hwmon0/temp1_max":   "81850\n",
+	"class/hwmon/hwmon0/temp1_crit":  "84850\n",
+
+	"class/hwmon/hwmon4/name":         "coretemp\n",
+	"class/hwmon/hwmon4/temp1_input":  "52000\n",
+	"class/hwmon/hwmon4/temp1_label":  "Package id 0\n",
+	"class/hwmon/hwmon4/temp1_crit":   "100000\n",
+	"class/hwmon/hwmon4/temp2_input":  "49000\n",
+	"class/hwmon/hwmon4/temp2_label":  "Core 0\n",
+	"class/hwmon/hwmon4/temp10_input": "51000\n",
+	"class/hwmon/hwmon4/temp10_crit":  "0\n",
+
+	"class/thermal/thermal_zone0/type":              "acpitz\n",
+	"class/thermal/thermal_zone0/temp":              "27800\n",
+	"class/thermal/thermal_zone0/trip_point_0_type": "critical\n",
+	"class/thermal/thermal_zone0/trip_point_0_temp": "119000\n",
+
+	"class/thermal/thermal_zone10/type":              "x86_pkg_temp\n",
+	"class/thermal/thermal_zone10/temp":              "53000\n",
+	"class/thermal/thermal_zone10/trip_point_0_type": "passive\n",
+	"class/thermal/thermal_zone10/trip_point_0_temp": "0\n",
+	"class/thermal/thermal_zone10/trip_point_1_type": "hot\n",
+	"class/thermal/thermal_zone10/trip_point_1_temp": "95000\n",
+
+	"class/thermal/thermal_zone2/type": "iwlwifi_1\n",
+}
+
+func TestReadHwmonTemperatures(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, temperatureTree)
+
+	sensors, err := readHwmonTemperatures(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []types.TemperatureSensor{
+		{ID: "hwmon0/temp1", Chip: "nvme", Label: "Composite", Celsius: 38.85, Max: ptr(81.85), Critical: ptr(84.85)},
+		{ID: "hwmon4/temp1", Chip: "coretemp", Label: "Package id 0", Celsius: 52, Critical: ptr(100.0)},
+		{ID: "hwmon4/temp2", Chip: "coretemp", Label: "Core 0", Celsius: 49},
+		{ID: "hwmon4/temp10", Chip: "coretemp", Label: "temp10", Celsius: 51},
+	}
+	assertSensors(t, sensors, want)
+}
+
+func TestReadThermalZones(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, temperatureTree)
+
+	sensors, err := readThermalZones(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// thermal_zone2 has no temp and is skipped
+	want := []types.TemperatureSensor{
+		{ID: "thermal_zone0", Chip: "acpitz", Label: "acpitz", Celsius: 27.8, Critical: ptr(119.0)},
+		{ID: "thermal_zone10", Chip: "x86_pkg_temp", Label: "x86_pkg_temp", Celsius: 53, Max: ptr(95.0)},
+	}
+	assertSensors(t, sensors, want)
+
+	if _, err := readThermalZones(t.TempDir()); err == nil {
+		t.Error("expected an error without thermal zones")
+	}
+}
+
+func TestFindPrimarySensor(t *testing.T) {
+	sensors := []types.TemperatureSensor{
+		{ID: "hwmon0/temp1", Chip: "nvme", Label: "Composite"},
+		{ID: "thermal_zone3", Chip: "x86_pkg_temp", Label: "x86_pkg_temp"},
+		{ID: "hwmon3/temp1", Chip: "k10temp", Label: "Tctl"},
+		{ID: "hwmon4/temp1", Chip: "coretemp", Label: "Package id 0"},
+	}
+
+	tests := []struct {
+		name    string
+		sensors []types.TemperatureSensor
+		primary string
+		want    string
+	}{
+		{"coretemp first", sensors, "", "hwmon4/temp1"},
+		{"k10temp before thermal zone", sensors[:3], "", "hwmon3/temp1"},
+		{"thermal zone", sensors[:2], "", "thermal_zone3"},
+		{"first sensor", sensors[:1], "", "hwmon0/temp1"},
+		{"no sensors", nil, "", ""},
+		{"by id", sensors, "hwmon0/temp1", "hwmon0/temp1"},
+		{"by chip and label", sensors, "nvme/Composite", "hwmon0/temp1"},
+		{"by label", sensors, "Tctl", "hwmon3/temp1"},
+		{"unknown", sensors, "acpitz", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findPrimarySensor(tt.sensors, tt.primary)
+			id := ""
+			if got != nil {
+				id = got.ID
+			}
+			if id != tt.want {
+				t.Errorf("primary = %q, want %q", id, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemperatureSnapshot(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, temperatureTree)
+	useConfig(t, "[temperature]\nsys_root = \""+root+"\"\n")
+
+	data, err := (&temperatureCollector{}).Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := data.(*types.TemperatureInfo)
+	if len(info.Sensors) != 6 {
+		t.Errorf("got %d sensors, want 6", len(info.Sensors))
+	}
+	if info.Primary == nil || info.Primary.ID != "hwmon4/temp1" {
+		t.Errorf("primary = %+v, want hwmon4/temp1", info.Primary)
+	}
+
+	useConfig(t, "[temperature]\nsys_root = \""+root+"\"\nprimary = \"Composite\"\n")
+	data, err = (&temperatureCollector{}).Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if primary := data.(*types.TemperatureInfo).Primary; primary == nil || primary.ID != "hwmon0/temp1" {
+		t.Errorf("configured primary = %+v, want hwmon0/temp1", primary)
+	}
+
+	useConfig(t, "[temperature]\nsys_root = \""+t.TempDir()+"\"\n")
+	if _, err := (&temperatureCollector{}).Snapshot(); err == nil {
+		t.Error("expected an error without hwmon and thermal zones")
+	}
+}
+
+func assertSensors(t *testing.T, got, want []types.TemperatureSensor) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d sensors %+v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.ID != w.ID || g.Chip != w.Chip || g.Label != w.Label || g.Celsius != w.Celsius {
+			t.Errorf("sensor %d = %+v, want %+v", i, g, w)
+		}
+		if !equalOptional(g.Max, w.Max) || !equalOptional(g.Critical, w.Critical) {
+			t.Errorf("sensor %s thresholds = %v/%v, want %v/%v", w.ID, deref(g.Max), deref(g.Critical), deref(w.Max), deref(w.Critical))
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package types
+
+// BatteryInfo is the state of a battery, or of all batteries combined
+type BatteryInfo struct {
+	Name        string   `json:"name,omitempty"` // power_supply name, empty for the aggregate
+	Percentage  int      `json:"percentage"`
+	State       string   `json:"state"`
+	EnergyNow   float64  `json:"energy_now_wh"`
+	EnergyFull  float64  `json:"energy_full_wh"`
+	Power       float64  `json:"power_w"`       // current charge or discharge rate
+	TimeToEmpty *float64 `json:"time_to_empty"` // in minutes, null when unknown
+	TimeToFull  *float64 `json:"time_to_full"`  // in minutes, null when unknown
+
+	// Only set on individual batteries
+	Health               *BatteryHealth `json:"health,omitempty"`
+	ChargeStartThreshold *int           `json:"charge_start_threshold,omitempty"` // percent, starts charging below
+	ChargeEndThreshold   *int           `json:"charge_end_threshold,omitempty"`   // percent, stops charging at
+}
+
+// BatteryHealth describes the identity and wear of a battery
+type BatteryHealth struct {
+	Manufacturer     string   `json:"manufacturer,omitempty"`
+	Model            string   `json:"model,omitempty"`
+	SerialNumber     string   `json:"serial_number,omitempty"`
+	Technology       string   `json:"technology,omitempty"` // e.g. "Li-ion"
+	CycleCount       *int     `json:"cycle_count"`          // null when not reported
+	EnergyFullDesign float64  `json:"energy_full_design_wh"`
+	Wear             *float64 `json:"wear_percent"` // capacity lost compared to the design, null when unknown
+}
+
+// ACAdapter is an external power supply such as a mains adapter or USB-C
+type ACAdapter struct {
+	Name   string `json:"name"`
+	Online bool   `json:"online"`
+}
+
+// PowerInfo lists every battery and adapter of the system. Total combines
+// all batteries weighted by their energy.
+type PowerInfo struct {
+	ACOnline  bool          `json:"ac_online"` // any adapter is online
+	Total     BatteryInfo   `json:"total"`
+	Batteries []BatteryInfo `json:"batteries"`
+	Adapters  []ACAdapter   `json:"adapters"`
+}
+
+// Kinds of BatteryEvent
+const (
+	BatteryLow             = "low"
+	BatteryChargingStarted = "charging_started"
+	BatteryChargingStopped = "charging_stopped"
+	BatteryFull            = "full"
+)
+
+// BatteryEvent is a transition of the combined battery state
+type BatteryEvent struct {
+	Event              string `json:"event"`
+	Level              int    `json:"level,omitempty"` // alert level crossed by a "low" event
+	PreviousPercentage int    `json:"previous_percentage"`
+	Percentage         int    `json:"percentage"`
+	PreviousState      string `json:"previous_state"`
+	State              string `json:"state"`
+}
@@ -0,0 +1,34 @@
+package types
+
+// CPUTimes is the share of time a CPU spent in each state during the last
+// interval, in percent
+type CPUTimes struct {
+	Usage  float64 `json:"usage"` // everything but idle and iowait
+	User   float64 `json:"user"`  // including nice
+	System float64 `json:"system"`
+	IOWait float64 `json:"iowait"`
+	IRQ    float64 `json:"irq"` // hard and soft interrupts
+	Steal  float64 `json:"steal"`
+	Idle   float64 `json:"idle"`
+}
+
+// CPUInfo is the CPU usage breakdown for all cores and each core
+type CPUInfo struct {
+	Model   string     `json:"model,omitempty"`
+	Total   CPUTimes   `json:"total"`
+	PerCore []CPUTimes `json:"per_core"`
+}
+
+// CPUCoreInfo describes frequency scaling and topology of a logical CPU.
+// Entries are in the same order as CPUPerCore.
+type CPUCoreInfo struct {
+	ID                          int    `json:"id"`
+	CurrentFreqMHz              int    `json:"cur_freq_mhz"`
+	MinFreqMHz                  int    `json:"min_freq_mhz"`
+	MaxFreqMHz                  int    `json:"max_freq_mhz"`
+	Governor                    string `json:"governor,omitempty"`
+	EnergyPerformancePreference string `json:"energy_performance_preference,omitempty"`
+	CoreType                    string `json:"core_type,omitempty"` // "performance" or "efficiency" on hybrid CPUs
+	CoreID                      int    `json:"core_id"`             // physical core within the package
+	SMTSiblings                 []int  `json:"smt_siblings"`        // logical CPUs sharing the physical core
+}
@@ -0,0 +1,26 @@
+package types
+
+// Filesystem is the space and inode usage of a mounted filesystem. Sizes
+// are in bytes.
+type Filesystem struct {
+	MountPoint        string  `json:"mount_point"`
+	Device            string  `json:"device"`
+	Type              string  `json:"type"`
+	ReadOnly          bool    `json:"read_only"`
+	Total             uint64  `json:"total"`
+	Used              uint64  `json:"used"`
+	Free              uint64  `json:"free"`         // available to unprivileged users
+	UsedPercent       float64 `json:"used_percent"` // used / (used + free), like df
+	Inodes            uint64  `json:"inodes"`
+	InodesUsed        uint64  `json:"inodes_used"`
+	InodesFree        uint64  `json:"inodes_free"`
+	InodesUsedPercent float64 `json:"inodes_used_percent"`
+}
+
+// DiskInfo lists the mounted filesystems. Added and Removed hold the mount
+// points that changed when the update was caused by a mount or unmount.
+type DiskInfo struct {
+	Filesystems []Filesystem `json:"filesystems"`
+	Added       []string     `json:"added,omitempty"`
+	Removed     []string     `json:"removed,omitempty"`
+}
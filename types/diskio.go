@@ -0,0 +1,18 @@
+package types
+
+// BlockDeviceIO is the I/O activity of a block device during the last
+// interval
+type BlockDeviceIO struct {
+	Name             string          `json:"name"`
+	ReadBytesPerSec  float64         `json:"read_bytes_per_sec"`
+	WriteBytesPerSec float64         `json:"write_bytes_per_sec"`
+	ReadIOPS         float64         `json:"read_iops"`
+	WriteIOPS        float64         `json:"write_iops"`
+	Utilization      float64         `json:"utilization"`          // percent of time the device was busy
+	Partitions       []BlockDeviceIO `json:"partitions,omitempty"` // only set on whole disks
+}
+
+// DiskIOInfo lists the I/O activity of every disk
+type DiskIOInfo struct {
+	Devices []BlockDeviceIO `json:"devices"`
+}
@@ -0,0 +1,14 @@
+package types
+
+// LoadInfo holds the host load indicators
+type LoadInfo struct {
+	Load1                 float64 `json:"load_1"`
+	Load5                 float64 `json:"load_5"`
+	Load15                float64 `json:"load_15"`
+	UptimeSeconds         float64 `json:"uptime_seconds"`
+	Processes             int     `json:"processes"`
+	Threads               int     `json:"threads"`
+	Running               int     `json:"running"` // runnable threads
+	Blocked               int     `json:"blocked"` // threads waiting for I/O
+	ContextSwitchesPerSec float64 `json:"context_switches_per_sec"`
+}
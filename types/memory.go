@@ -0,0 +1,27 @@
+package types
+
+// MemoryInfo is a breakdown of RAM and swap usage in bytes
+type MemoryInfo struct {
+	Total       uint64       `json:"total"`
+	Available   uint64       `json:"available"`    // can be allocated without swapping
+	Used        uint64       `json:"used"`         // total - available, excludes reclaimable caches
+	UsedPercent float64      `json:"used_percent"` // used / total
+	Free        uint64       `json:"free"`
+	Cached      uint64       `json:"cached"` // page cache and reclaimable slab
+	Buffers     uint64       `json:"buffers"`
+	Shared      uint64       `json:"shared"`
+	Dirty       uint64       `json:"dirty"`
+	SwapTotal   uint64       `json:"swap_total"`
+	SwapUsed    uint64       `json:"swap_used"`
+	Zram        []ZramDevice `json:"zram"`
+}
+
+// ZramDevice reports how well a zram device compresses its contents
+type ZramDevice struct {
+	Name             string  `json:"name"`
+	DiskSize         uint64  `json:"disk_size"`         // configured uncompressed capacity
+	OriginalSize     uint64  `json:"original_size"`     // uncompressed size of the stored data
+	CompressedSize   uint64  `json:"compressed_size"`   // compressed size of the stored data
+	MemoryUsed       uint64  `json:"memory_used"`       // RAM used, including allocator overhead
+	CompressionRatio float64 `json:"compression_ratio"` // original / compressed, 0 when empty
+}
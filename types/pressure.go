@@ -0,0 +1,26 @@
+package types
+
+// PressureStall is the share of wall time in which tasks stalled on a
+// resource, in percent, averaged over 10, 60 and 300 seconds
+type PressureStall struct {
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+	Total  uint64  `json:"total_us"` // cumulative stall time in microseconds
+}
+
+// PressureResource holds the "some" (at least one task stalled) and "full"
+// (all non-idle tasks stalled) lines of a /proc/pressure file. Full is nil
+// when the kernel does not report it.
+type PressureResource struct {
+	Some PressureStall  `json:"some"`
+	Full *PressureStall `json:"full"`
+}
+
+// PressureInfo is the Pressure Stall Information of the host
+type PressureInfo struct {
+	CPU     PressureResource `json:"cpu"`
+	Memory  PressureResource `json:"memory"`
+	IO      PressureResource `json:"io"`
+	Trigger string           `json:"trigger,omitempty"` // configured trigger that caused this update
+}
@@ -0,0 +1,19 @@
+package types
+
+// ProcessInfo describes a running process
+type ProcessInfo struct {
+	PID           int     `json:"pid"`
+	Command       string  `json:"command"` // executable name, at most 15 characters
+	Cmdline       string  `json:"cmdline,omitempty"`
+	User          string  `json:"user"`
+	CPUPercent    float64 `json:"cpu_percent"` // of one core, like top
+	RSS           uint64  `json:"rss"`         // resident memory in bytes
+	MemoryPercent float64 `json:"memory_percent"`
+}
+
+// ProcessesInfo lists the processes using the most CPU time and the most
+// resident memory
+type ProcessesInfo struct {
+	ByCPU    []ProcessInfo `json:"by_cpu"`
+	ByMemory []ProcessInfo `json:"by_memory"`
+}
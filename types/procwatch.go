@@ -0,0 +1,30 @@
+package types
+
+// Actions of a ProcessEvent
+const (
+	ProcessStarted = "started"
+	ProcessExited  = "exited"
+)
+
+// WatchedProcess reports whether processes matching a configured pattern
+// are running
+type WatchedProcess struct {
+	Pattern string `json:"pattern"`
+	Running bool   `json:"running"`
+	PIDs    []int  `json:"pids"`
+}
+
+// ProcessEvent is a watched process starting or exiting
+type ProcessEvent struct {
+	Action   string   `json:"action"`
+	PID      int      `json:"pid"`
+	Command  string   `json:"command"`
+	Patterns []string `json:"patterns"` // configured patterns the process matches
+}
+
+// ProcWatchInfo is the presence of every watched process. Event is set when
+// the update was caused by a watched process starting or exiting.
+type ProcWatchInfo struct {
+	Processes []WatchedProcess `json:"processes"`
+	Event     *ProcessEvent    `json:"event,omitempty"`
+}
@@ -0,0 +1,41 @@
+package types
+
+// TemperatureSensor is a single temperature reading in degrees Celsius
+type TemperatureSensor struct {
+	ID       string   `json:"id"`    // stable within a boot, e.g. "hwmon2/temp1" or "thermal_zone0"
+	Chip     string   `json:"chip"`  // hwmon driver name or thermal zone type, e.g. "coretemp"
+	Label    string   `json:"label"` // e.g. "Package id 0" or "Composite"
+	Celsius  float64  `json:"celsius"`
+	Max      *float64 `json:"max,omitempty"`
+	Critical *float64 `json:"critical,omitempty"`
+}
+
+// TemperatureInfo lists all temperature sensors and the one bars should show
+type TemperatureInfo struct {
+	Primary *TemperatureSensor  `json:"primary"`
+	Sensors []TemperatureSensor `json:"sensors"`
+}
+
+// Fan is a fan speed reading
+type Fan struct {
+	ID    string `json:"id"` // e.g. "hwmon5/fan1"
+	Chip  string `json:"chip"`
+	Label string `json:"label"`
+	RPM   int    `json:"rpm"`
+}
+
+// PWM is a fan control channel
+type PWM struct {
+	ID           string  `json:"id"` // e.g. "hwmon5/pwm1", used with SET FANS
+	Chip         string  `json:"chip"`
+	Label        string  `json:"label"`
+	DutyCycle    float64 `json:"duty_cycle"`     // percent
+	Mode         string  `json:"mode,omitempty"` // "full", "manual" or "auto"
+	Controllable bool    `json:"controllable"`   // SET FANS is allowed and the daemon can write the channel
+}
+
+// FanInfo lists fan speeds and PWM channels
+type FanInfo struct {
+	Fans []Fan `json:"fans"`
+	PWM  []PWM `json:"pwm"`
+}
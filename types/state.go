@@ -5,19 +5,14 @@ type CurrentStateData struct {
 	Time  			string 				`json:"time"`
 	CPUPerCore  []float64    	`json:"cpu_per_core"`
 	CPUAverage  float64      	`json:"cpu_average"`
+	CPUCores    []CPUCoreInfo	`json:"cpu_cores"`
 	MemoryUsed 	int   				`json:"memory_used"`
 	MemoryTotal int   				`json:"memory_total"`
+	Memory      MemoryInfo    `json:"memory"`
 	Battery 		BatteryInfo		`json:"battery"`
 	Network 		NetworkInfo		`json:"network"`
 }
 
-type BatteryInfo struct {
-		Percentage 	int 		`json:"percentage"`
-		State 			string 	`json:"state"`
-		TimeToEmpty float64	`json:"time_to_empty"`
-		TimeToFull  float64	`json:"time_to_full"`
-}
-
 type NetworkInfo struct {
   Interface      string  `json:"interface"`
   IPAddress      string  `json:"ip_address"`
@@ -0,0 +1,21 @@
+package types
+
+import "time"
+
+// Collector states reported in CollectorStatus
+const (
+	CollectorRunning    = "running"
+	CollectorRestarting = "restarting"
+	CollectorStopped    = "stopped"
+)
+
+// CollectorStatus describes the health of a single collector
+type CollectorStatus struct {
+	State         string     `json:"state"`
+	Since         time.Time  `json:"since"`                     // when State was entered
+	Restarts      int        `json:"restarts"`                  // restarts since the collector was started
+	Degraded      bool       `json:"degraded"`                  // restarting, or failing since the last success
+	LastSuccess   *time.Time `json:"last_success"`              // last update published by the collector
+	LastError     string     `json:"last_error,omitempty"`      // most recent error
+	LastErrorTime *time.Time `json:"last_error_time,omitempty"` // when LastError happened
+}
@@ -0,0 +1,11 @@
+package types
+
+import "encoding/json"
+
+// WatchInfo is the state of a file or directory watched with inotify
+type WatchInfo struct {
+	Path    string          `json:"path"`
+	Exists  bool            `json:"exists"`
+	Content json.RawMessage `json:"content,omitempty"` // file content, JSON or string
+	Count   *int            `json:"count,omitempty"`   // number of directory entries
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Multicast group of uevents sent by the kernel; udev rebroadcasts them
+// on group 2 after processing
+const ueventKernelGroup = 1
+
+// Delay to coalesce the burst of uevents sent when a charger is plugged in,
+// which changes the adapter and every battery
+const powerSupplySettle = 100 * time.Millisecond
+
+// pollPowerSupplies calls poll now and every interval, and changed right
+// after the kernel reports a change of a power supply, until ctx is
+// cancelled. If uevents cannot be received it falls back to polling alone.
+func pollPowerSupplies(ctx context.Context, interval func() time.Duration, poll, changed func()) {
+	uevents := make(chan struct{}, 1)
+	f, err := openNetlink(unix.NETLINK_KOBJECT_UEVENT, ueventKernelGroup)
+	if err != nil {
+		log.Printf("Power supply uevents unavailable, polling only: %v", err)
+	} else {
+		defer f.Close()
+		stop := context.AfterFunc(ctx, func() { f.Close() })
+		defer stop()
+		go readPowerSupplyUevents(ctx, f, uevents)
+	}
+
+	watchPowerSupplies(ctx, interval, poll, changed, uevents)
+}
+
+// watchPowerSupplies runs the loop of pollPowerSupplies on the signals in
+// uevents. The poll deadline is not moved by uevents, so a stream of them
+// cannot delay the next poll.
+func watchPowerSupplies(ctx context.Context, interval func() time.Duration, poll, changed func(), uevents <-chan struct{}) {
+	poll()
+	timer := time.NewTimer(interval())
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-uevents:
+			time.Sleep(powerSupplySettle)
+			select {
+			case <-uevents:
+			default:
+			}
+			changed()
+		case <-timer.C:
+			poll()
+			timer.Reset(interval())
+		}
+	}
+}
+
+// readPowerSupplyUevents signals changed for every power_supply uevent read
+// from f until ctx is cancelled or reading fails
+func readPowerSupplyUevents(ctx context.Context, f *os.File, changed chan<- struct{}) {
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+
+	buf := make([]byte, os.Getpagesize())
+	for {
+		n, err := f.Read(buf)
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, unix.ENOBUFS) {
+			// Events were dropped, one of them may have been ours
+			notify()
+			continue
+		}
+		if err != nil {
+			log.Printf("Error reading uevents, polling power supplies only: %v", err)
+			return
+		}
+
+		// "change@/devices/.../power_supply/AC\0ACTION=change\0SUBSYSTEM=power_supply\0..."
+		for _, field := range bytes.Split(buf[:n], []byte{0}) {
+			if string(field) == "SUBSYSTEM=power_supply" {
+				notify()
+				break
+			}
+		}
+	}
+}
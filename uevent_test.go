@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchPowerSuppliesPollsDuringUevents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var polls, changes atomic.Int32
+	uevents := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watchPowerSupplies(ctx, func() time.Duration { return 50 * time.Millisecond },
+			func() { polls.Add(1) }, func() { changes.Add(1) }, uevents)
+	}()
+
+	// A uevent arrives faster than the interval the whole time
+	deadline := time.Now().Add(600 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		select {
+		case uevents <- struct{}{}:
+		default:
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if changes.Load() == 0 {
+		t.Error("changed was never called")
+	}
+	// One poll at startup, and more while uevents keep arriving
+	if got := polls.Load(); got < 3 {
+		t.Errorf("poll called %d times, want at least 3", got)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/GcZuRi1886/system-info-provider/types"
+	"golang.org/x/sys/unix"
+)
+
+// What a watch reports
+const (
+	// watchReportContent publishes the content of a file
+	watchReportContent = "content"
+	// watchReportCount publishes the number of entries in a directory
+	watchReportCount = "count"
+)
+
+// Events that change the content of a file or the entries of a directory
+const watchEvents = unix.IN_CLOSE_WRITE | unix.IN_MODIFY | unix.IN_CREATE | unix.IN_DELETE |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF
+
+// Delay to coalesce bursts of events, e.g. a file written in several chunks
+const watchSettle = 50 * time.Millisecond
+
+// watchCollector publishes a file or directory configured in a [[watch]]
+// table whenever it changes, e.g.
+//
+//	[[watch]]
+//	name = "recording"
+//	path = "/tmp/recording.status"
+//
+//	[[watch]]
+//	name = "mail"
+//	path = "~/Mail/INBOX/new"
+//	report = "count"
+type watchCollector struct {
+	name   string
+	path   string
+	report string
+}
+
+func init() {
+	onConfigLoad(registerWatches)
+}
+
+// registerWatches registers a collector for every enabled [[watch]] table
+func registerWatches(cfg *Config) {
+	registerDefined(cfg, "watch", func(section Section) (definedCollector, error) {
+		return newWatchCollector(section)
+	})
+}
+
+// newWatchCollector validates a [[watch]] table
+func newWatchCollector(section Section) (*watchCollector, error) {
+	name := strings.ToLower(section.String("name", ""))
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return nil, fmt.Errorf("invalid watch name %q", name)
+	}
+
+	path := section.String("path", "")
+	if path == "" {
+		return nil, fmt.Errorf("watch %s has no path", name)
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("watch %s: %w", name, err)
+		}
+		path = filepath.Join(home, rest)
+	}
+
+	report := section.String("report", watchReportContent)
+	if report != watchReportContent && report != watchReportCount {
+		return nil, fmt.Errorf("watch %s has unknown report %q", name, report)
+	}
+
+	return &watchCollector{name: name, path: filepath.Clean(path), report: report}, nil
+}
+
+func (w *watchCollector) definition() string {
+	return w.path + " " + w.report
+}
+
+// Name returns the data type name
+func (w *watchCollector) Name() string {
+	return w.name
+}
+
+// Interval returns zero, watches are event driven
+func (w *watchCollector) Interval() time.Duration {
+	return 0
+}
+
+// Snapshot reads the watched file or directory
+func (w *watchCollector) Snapshot() (any, error) {
+	info := &types.WatchInfo{Path: w.path}
+
+	if w.report == watchReportCount {
+		entries, err := os.ReadDir(w.path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		count := len(entries)
+		info.Exists = err == nil
+		info.Count = &count
+		return info, nil
+	}
+
+	data, err := os.ReadFile(w.path)
+	if os.IsNotExist(err) {
+		return info, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	info.Exists = true
+	info.Content = jsonValue(strings.TrimSpace(string(data)))
+	return info, nil
+}
+
+// Start watches the path with inotify and emits on every change until ctx
+// is cancelled
+func (w *watchCollector) Start(ctx context.Context, emit EmitFunc) error {
+	fd, err := unix.InotifyInit1(unix.IN_NONBLOCK | unix.IN_CLOEXEC)
+	if err != nil {
+		return fmt.Errorf("inotify init: %w", err)
+	}
+	// A non-blocking fd wrapped in os.File uses the runtime poller, so
+	// closing it unblocks a pending Read
+	f := os.NewFile(uintptr(fd), "inotify")
+	defer f.Close()
+	stop := context.AfterFunc(ctx, func() { f.Close() })
+	defer stop()
+
+	// Files are watched through their parent directory, so they are seen
+	// when created, deleted or atomically replaced by a rename
+	dir, base := w.path, ""
+	if w.report == watchReportContent {
+		dir, base = filepath.Dir(w.path), filepath.Base(w.path)
+	}
+	if _, err := unix.InotifyAddWatch(fd, dir, watchEvents); err != nil {
+		return fmt.Errorf("watch %s: %w", dir, err)
+	}
+
+	var last any
+	emitIfChanged := func() {
+		info, err := w.Snapshot()
+		if err != nil {
+			reportCollectorError(w.name, fmt.Errorf("error reading %s: %w", w.path, err))
+			return
+		}
+		if reflect.DeepEqual(info, last) {
+			return
+		}
+		last = info
+		emit(w.name, wrapData(w, info))
+	}
+	emitIfChanged()
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := f.Read(buf)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return fmt.Errorf("read inotify events: %w", err)
+		}
+
+		changed, gone := w.parseEvents(buf[:n], base)
+		if gone {
+			return fmt.Errorf("watched directory %s was removed", dir)
+		}
+		if changed {
+			time.Sleep(watchSettle)
+			emitIfChanged()
+		}
+	}
+}
+
+// parseEvents reports whether the events in buf concern the watched path
+// and whether the watched directory itself disappeared
+func (w *watchCollector) parseEvents(buf []byte, base string) (changed, gone bool) {
+	for offset := 0; offset+unix.SizeofInotifyEvent <= len(buf); {
+		event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameBytes := buf[offset+unix.SizeofInotifyEvent : offset+unix.SizeofInotifyEvent+int(event.Len)]
+		name := strings.TrimRight(string(nameBytes), "\x00")
+		offset += unix.SizeofInotifyEvent + int(event.Len)
+
+		if event.Mask&(unix.IN_DELETE_SELF|unix.IN_MOVE_SELF|unix.IN_IGNORED) != 0 {
+			gone = true
+			continue
+		}
+		if base == "" || name == base {
+			changed = true
+		}
+	}
+	return changed, gone
+}
@@ -1,6 +1,11 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
 	"github.com/GcZuRi1886/system-info-provider/types"
 )
 
@@ -8,12 +13,14 @@ import (
 type WorkspaceProvider interface {
 	// GetWorkspaceState retrieves the current workspace state
 	GetWorkspaceState() (*types.WorkspaceInfo, error)
-	// Listen starts listening for workspace events and calls emit on changes
-	Listen(emit func(dataType string, data any))
+	// Listen listens for workspace events and calls emit on changes until ctx is cancelled
+	Listen(ctx context.Context, emit EmitFunc) error
 	// Name returns the compositor name
 	Name() string
 }
 
+var errNoCompositor = errors.New("no supported compositor detected")
+
 // NewWorkspaceProvider creates a workspace provider for the detected compositor
 func NewWorkspaceProvider() WorkspaceProvider {
 	compositor := DetectCompositor()
@@ -26,3 +33,41 @@ func NewWorkspaceProvider() WorkspaceProvider {
 		return nil
 	}
 }
+
+// workspaceCollector publishes the workspace state of the detected compositor
+type workspaceCollector struct{}
+
+func init() {
+	RegisterCollector(&workspaceCollector{})
+	// Legacy: still supported for backwards compatibility
+	registerAlias("hyprland", "workspace")
+}
+
+// Name returns the data type name
+func (w *workspaceCollector) Name() string {
+	return "workspace"
+}
+
+// Interval returns zero, workspace updates are event driven
+func (w *workspaceCollector) Interval() time.Duration {
+	return 0
+}
+
+// Snapshot returns the current workspace state (compositor-agnostic)
+func (w *workspaceCollector) Snapshot() (any, error) {
+	provider := NewWorkspaceProvider()
+	if provider == nil {
+		return nil, errNoCompositor
+	}
+	return provider.GetWorkspaceState()
+}
+
+// Start listens for workspace events of the detected compositor
+func (w *workspaceCollector) Start(ctx context.Context, emit EmitFunc) error {
+	provider := NewWorkspaceProvider()
+	if provider == nil {
+		return errNoCompositor
+	}
+	log.Printf("Detected compositor: %s", provider.Name())
+	return provider.Listen(ctx, emit)
+}